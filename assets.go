@@ -0,0 +1,8 @@
+// Package prq holds the default prompt templates and JSON schemas, embedded
+// so an installed binary works outside the source checkout.
+package prq
+
+import "embed"
+
+//go:embed prompts/*.txt schemas/*.json
+var Assets embed.FS
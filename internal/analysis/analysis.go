@@ -0,0 +1,169 @@
+// Package analysis parses the output of static analyzers such as go vet,
+// staticcheck, and golangci-lint into file/line findings.
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/diff"
+)
+
+// Output formats understood by Parse.
+const (
+	// FormatText is "path:line[:col]: message" per line, as printed by go
+	// vet, staticcheck, and golangci-lint's line-number output.
+	FormatText = "text"
+	// FormatGolangciJSON is `golangci-lint run --out-format json`.
+	FormatGolangciJSON = "golangci-json"
+)
+
+// Finding is one diagnostic reported by an analyzer.
+type Finding struct {
+	Tool    string `json:"tool"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+var (
+	textLineRE = regexp.MustCompile(`^(\S[^:]*\.[A-Za-z0-9]+):(\d+)(?::(\d+))?:\s*(.+)$`)
+	// staticcheck and golangci-lint append the check name in parentheses.
+	trailingRuleRE = regexp.MustCompile(`\s+\(([A-Za-z][A-Za-z0-9_-]*)\)$`)
+)
+
+// Parse reads the output of tool in format. Paths are made relative to root,
+// the directory the analyzer ran in, and use forward slashes.
+func Parse(tool string, format string, output string, root string) ([]Finding, error) {
+	switch format {
+	case "", FormatText:
+		return parseText(tool, output, root), nil
+	case FormatGolangciJSON:
+		return parseGolangciJSON(tool, output, root)
+	default:
+		return nil, fmt.Errorf("unknown analyzer format %q", format)
+	}
+}
+
+func parseText(tool string, output string, root string) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "vet: "))
+		m := textLineRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		lineNo, err := strconv.Atoi(m[2])
+		if err != nil || lineNo <= 0 {
+			continue
+		}
+		col, _ := strconv.Atoi(m[3])
+		finding := Finding{Tool: tool, File: relPath(m[1], root), Line: lineNo, Column: col, Message: strings.TrimSpace(m[4])}
+		if rule := trailingRuleRE.FindStringSubmatch(finding.Message); rule != nil {
+			finding.Rule = rule[1]
+			finding.Message = strings.TrimSpace(strings.TrimSuffix(finding.Message, rule[0]))
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+type golangciReport struct {
+	Issues []struct {
+		FromLinter string `json:"FromLinter"`
+		Text       string `json:"Text"`
+		Pos        struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+			Column   int    `json:"Column"`
+		} `json:"Pos"`
+	} `json:"Issues"`
+}
+
+func parseGolangciJSON(tool string, output string, root string) ([]Finding, error) {
+	// golangci-lint may print warnings before the report.
+	start := strings.Index(output, "{")
+	if start < 0 {
+		return nil, nil
+	}
+	var report golangciReport
+	if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&report); err != nil {
+		return nil, fmt.Errorf("parse golangci-lint json: %w", err)
+	}
+	findings := make([]Finding, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		if issue.Pos.Filename == "" || issue.Pos.Line <= 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Tool:    tool,
+			File:    relPath(issue.Pos.Filename, root),
+			Line:    issue.Pos.Line,
+			Column:  issue.Pos.Column,
+			Rule:    issue.FromLinter,
+			Message: strings.TrimSpace(issue.Text),
+		})
+	}
+	return findings, nil
+}
+
+func relPath(path string, root string) string {
+	if filepath.IsAbs(path) && root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(path), "./")
+}
+
+// OnChangedLines keeps the findings on lines the diff adds, sorted by file
+// and line, with duplicates removed.
+func OnChangedLines(findings []Finding, posMap diff.PositionMap) []Finding {
+	seen := map[string]bool{}
+	var kept []Finding
+	for _, f := range findings {
+		if !posMap.IsAddedLine(f.File, f.Line) {
+			continue
+		}
+		key := fmt.Sprintf("%s\x00%s:%d\x00%s", f.Tool, f.File, f.Line, f.Message)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, f)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].File != kept[j].File {
+			return kept[i].File < kept[j].File
+		}
+		return kept[i].Line < kept[j].Line
+	})
+	return kept
+}
+
+// Label is the tool name with the rule, e.g. "staticcheck SA4006".
+func (f Finding) Label() string {
+	if f.Rule == "" || f.Rule == f.Tool {
+		return f.Tool
+	}
+	return f.Tool + " " + f.Rule
+}
+
+// Render lists findings one per line for the review prompt.
+func Render(findings []Finding) string {
+	if len(findings) == 0 {
+		return "None on changed lines"
+	}
+	var b strings.Builder
+	for _, f := range findings {
+		fmt.Fprintf(&b, "- %s:%d [%s] %s\n", f.File, f.Line, f.Label(), f.Message)
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/brianndofor/prq/internal/diff"
+)
+
+func TestParseText(t *testing.T) {
+	output := "# example.com/app/auth\n" +
+		"vet: auth/token.go:12:2: unreachable code\n" +
+		"/work/app/auth/token.go:20:5: this value of err is never used (SA4006)\n" +
+		"./main.go:3: missing return\n" +
+		"exit status 1\n"
+
+	findings, err := Parse("staticcheck", FormatText, output, "/work/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+	if findings[0].File != "auth/token.go" || findings[0].Line != 12 || findings[0].Column != 2 || findings[0].Message != "unreachable code" {
+		t.Fatalf("unexpected vet finding: %+v", findings[0])
+	}
+	if findings[1].File != "auth/token.go" || findings[1].Rule != "SA4006" || findings[1].Message != "this value of err is never used" {
+		t.Fatalf("unexpected staticcheck finding: %+v", findings[1])
+	}
+	if findings[2].File != "main.go" || findings[2].Line != 3 || findings[2].Column != 0 {
+		t.Fatalf("unexpected finding without column: %+v", findings[2])
+	}
+}
+
+func TestParseGolangciJSON(t *testing.T) {
+	output := "level=warning msg=\"deprecated linter\"\n" +
+		`{"Issues":[{"FromLinter":"errcheck","Text":"Error return value is not checked","Pos":{"Filename":"auth/token.go","Line":7,"Column":9}},` +
+		`{"FromLinter":"typecheck","Text":"no position","Pos":{"Filename":"","Line":0}}],"Report":{}}`
+
+	findings, err := Parse("golangci-lint", FormatGolangciJSON, output, "/work/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Rule != "errcheck" || findings[0].Line != 7 || findings[0].Label() != "golangci-lint errcheck" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if _, err := Parse("golangci-lint", FormatGolangciJSON, "{not json", ""); err == nil {
+		t.Fatalf("expected an error for malformed json")
+	}
+}
+
+func TestOnChangedLines(t *testing.T) {
+	files, err := diff.ParseUnified("diff --git a/auth/token.go b/auth/token.go\n" +
+		"--- a/auth/token.go\n" +
+		"+++ b/auth/token.go\n" +
+		"@@ -10,3 +10,4 @@\n" +
+		" a\n" +
+		"+b\n" +
+		"+c\n" +
+		" d\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	posMap, err := diff.BuildPositionMap(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	findings := []Finding{
+		{Tool: "vet", File: "auth/token.go", Line: 12, Message: "second"},
+		{Tool: "vet", File: "auth/token.go", Line: 10, Message: "context line"},
+		{Tool: "vet", File: "auth/token.go", Line: 11, Message: "first"},
+		{Tool: "vet", File: "auth/token.go", Line: 11, Message: "first"},
+		{Tool: "vet", File: "other.go", Line: 11, Message: "other file"},
+	}
+	kept := OnChangedLines(findings, posMap)
+	if len(kept) != 2 || kept[0].Message != "first" || kept[1].Message != "second" {
+		t.Fatalf("unexpected findings: %+v", kept)
+	}
+	if got := Render(kept); got != "- auth/token.go:11 [vet] first\n- auth/token.go:12 [vet] second" {
+		t.Fatalf("unexpected render: %q", got)
+	}
+}
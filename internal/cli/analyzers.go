@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/analysis"
+	"github.com/brianndofor/prq/internal/diff"
+	"github.com/brianndofor/prq/internal/provider"
+)
+
+const noAnalyzers = "No analyzers configured in prq.yaml."
+
+// runAnalyzers runs the configured analyzers in repoDir the way test commands
+// run, and returns their findings on lines diffText adds. An analyzer that
+// cannot run, times out, or prints unparseable output is reported in notes
+// instead of failing the review.
+func runAnalyzers(ctx context.Context, app *App, repoDir string, diffText string) ([]analysis.Finding, []string, error) {
+	analyzers := app.RepoConfig.Analyzers
+	if len(analyzers) == 0 {
+		return nil, nil, nil
+	}
+	files, err := diff.ParseUnified(diffText)
+	if err != nil {
+		return nil, nil, err
+	}
+	posMap, err := diff.BuildPositionMap(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := app.RepoConfig.Tests
+	timeout, err := testTimeout(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	env := testEnv(os.Environ(), cfg.EnvAllowlist)
+
+	var findings []analysis.Finding
+	var notes []string
+	for _, analyzer := range analyzers {
+		name, args, runEnv, err := testInvocation(cfg, repoDir, analyzer.Command, env)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Parse the full stdout; only the capped copy is kept in res.
+		var stdout bytes.Buffer
+		opts := ExecOptions{Env: runEnv, Timeout: timeout, MaxOutputBytes: cfg.MaxOutputBytes, StdoutSink: &stdout}
+		res, err := app.Exec.RunLimited(ctx, repoDir, opts, name, args...)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %v", analyzer.Name, err))
+			continue
+		}
+		if res.TimedOut {
+			notes = append(notes, fmt.Sprintf("%s: timed out after %s", analyzer.Name, timeout))
+			continue
+		}
+		output := stdout.String()
+		if analyzer.Format == analysis.FormatText {
+			// go vet reports on stderr.
+			output += "\n" + res.Stderr
+		}
+		parsed, err := analysis.Parse(analyzer.Name, analyzer.Format, output, repoDir)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %v", analyzer.Name, err))
+			continue
+		}
+		if len(parsed) == 0 && res.ExitCode != 0 {
+			firstLine, _, _ := strings.Cut(strings.TrimSpace(res.Stderr), "\n")
+			notes = append(notes, fmt.Sprintf("%s: exit %d with no parseable findings: %s", analyzer.Name, res.ExitCode, firstLine))
+		}
+		findings = append(findings, parsed...)
+	}
+	return analysis.OnChangedLines(findings, posMap), notes, nil
+}
+
+// renderAnalyzerFindings is the {ANALYZER_FINDINGS} section of the prompt.
+func renderAnalyzerFindings(findings []analysis.Finding, notes []string) string {
+	out := analysis.Render(findings)
+	if len(notes) > 0 {
+		out += "\nAnalyzer problems:\n- " + strings.Join(notes, "\n- ")
+	}
+	return out
+}
+
+// findingIssues turns analyzer findings into draft issues tagged with the
+// tool, so they are posted as inline comments next to the model's issues.
+func findingIssues(findings []analysis.Finding) []provider.Issue {
+	issues := make([]provider.Issue, 0, len(findings))
+	for _, f := range findings {
+		issues = append(issues, provider.Issue{
+			Severity:  "minor",
+			Category:  "correctness",
+			File:      f.File,
+			StartLine: f.Line,
+			EndLine:   f.Line,
+			Message:   f.Message,
+			Tool:      f.Label(),
+		})
+	}
+	return issues
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brianndofor/prq/internal/config"
+)
+
+// scriptedExecRunner returns a canned result for each `sh -c` command.
+type scriptedExecRunner struct {
+	FakeExecRunner
+	results map[string]ExecResult
+}
+
+func (r scriptedExecRunner) RunLimited(ctx context.Context, dir string, opts ExecOptions, name string, args ...string) (ExecResult, error) {
+	res, ok := r.results[args[len(args)-1]]
+	if !ok {
+		return r.FakeExecRunner.RunLimited(ctx, dir, opts, name, args...)
+	}
+	if opts.StdoutSink != nil {
+		_, _ = opts.StdoutSink.Write([]byte(res.Stdout))
+	}
+	return res, nil
+}
+
+func TestRunAnalyzersKeepsChangedLines(t *testing.T) {
+	repoDir := t.TempDir()
+	app := &App{RepoConfig: config.DefaultRepoConfig()}
+	app.RepoConfig.Analyzers = []config.AnalyzerConfig{
+		{Name: "go vet", Command: "go vet ./...", Format: "text"},
+		{Name: "golangci-lint", Command: "golangci-lint run --out-format json", Format: "golangci-json"},
+		{Name: "broken", Command: "broken-linter", Format: "text"},
+	}
+	app.Exec = scriptedExecRunner{results: map[string]ExecResult{
+		"go vet ./...":                        {ExitCode: 1, Stderr: "# example.com/app/auth\nvet: auth/token.go:11:2: unreachable code\nauth/token.go:30:1: unchanged line\n"},
+		"golangci-lint run --out-format json": {ExitCode: 1, Stdout: `{"Issues":[{"FromLinter":"errcheck","Text":"Error return value is not checked","Pos":{"Filename":"auth/token.go","Line":12,"Column":3}}]}`},
+		"broken-linter":                       {ExitCode: 2, Stderr: "broken-linter: config not found\nusage: ..."},
+	}}
+	diffText := "diff --git a/auth/token.go b/auth/token.go\n" +
+		"--- a/auth/token.go\n" +
+		"+++ b/auth/token.go\n" +
+		"@@ -10,2 +10,4 @@\n" +
+		" a\n" +
+		"+b\n" +
+		"+c\n" +
+		" d\n"
+
+	findings, notes, err := runAnalyzers(context.Background(), app, repoDir, diffText)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(findings) != 2 || findings[0].Tool != "go vet" || findings[1].Label() != "golangci-lint errcheck" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if len(notes) != 1 || notes[0] != "broken: exit 2 with no parseable findings: broken-linter: config not found" {
+		t.Fatalf("unexpected notes: %q", notes)
+	}
+
+	summary := renderAnalyzerFindings(findings, notes)
+	if !strings.Contains(summary, "- auth/token.go:11 [go vet] unreachable code") || !strings.Contains(summary, "Analyzer problems:\n- broken:") {
+		t.Fatalf("unexpected summary: %q", summary)
+	}
+
+	issues := findingIssues(findings)
+	if len(issues) != 2 || issues[1].Tool != "golangci-lint errcheck" || issues[1].StartLine != 12 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+	if body := renderIssueCommentBody(issues[1]); body != "[tool: golangci-lint errcheck] Error return value is not checked" {
+		t.Fatalf("unexpected comment body: %q", body)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/brianndofor/prq/internal/config"
 	"github.com/brianndofor/prq/internal/github"
 	"github.com/brianndofor/prq/internal/provider"
+	"github.com/brianndofor/prq/internal/redact"
 	"github.com/brianndofor/prq/internal/store"
 )
 
@@ -21,6 +22,12 @@ type App struct {
 	Provider   provider.Runner
 	Exec       ExecRunner
 	Store      *store.Store
+	// Redactor applies the built-in and configured redaction rules; nil
+	// means the built-in rules only.
+	Redactor *redact.Redactor
+	// RepoSources are the layers RepoConfig was merged from, lowest
+	// precedence first.
+	RepoSources []config.RepoSource
 }
 
 func withApp(ctx context.Context, app *App) context.Context {
@@ -40,6 +47,18 @@ func initApp(configPath string) (*App, error) {
 	if err != nil {
 		return nil, err
 	}
+	local, err := config.LocalRepoSource()
+	if err != nil {
+		return nil, err
+	}
+	var sources []config.RepoSource
+	if local != nil {
+		sources = append(sources, *local)
+	}
+	redactor, err := newRedactor(merged, repoCfg)
+	if err != nil {
+		return nil, err
+	}
 
 	var ghRunner github.Runner = github.RealRunner{}
 	var prov provider.Runner = provider.NewClaudeRunner(merged.Provider)
@@ -70,11 +89,25 @@ func initApp(configPath string) (*App, error) {
 	}
 
 	return &App{
-		Config:     merged,
-		RepoConfig: repoCfg,
-		GH:         gh,
-		Provider:   prov,
-		Exec:       execRunner,
-		Store:      st,
+		Config:      merged,
+		RepoConfig:  repoCfg,
+		GH:          gh,
+		Provider:    prov,
+		Exec:        execRunner,
+		Store:       st,
+		Redactor:    redactor,
+		RepoSources: sources,
 	}, nil
 }
+
+// newRedactor compiles the user's and the repo's redaction rules,
+// allowlists, and deny-lists once for the whole run.
+func newRedactor(cfg config.Config, repoCfg config.RepoConfig) (*redact.Redactor, error) {
+	var opts redact.Options
+	for _, rule := range append(append([]config.RedactionRule{}, cfg.Redaction.Rules...), repoCfg.Redaction.Rules...) {
+		opts.Rules = append(opts.Rules, redact.Rule{Name: rule.Name, Pattern: rule.Pattern})
+	}
+	opts.Allowlist = append(append([]string{}, cfg.Redaction.Allowlist...), repoCfg.Redaction.Allowlist...)
+	opts.DenyList = append(append([]string{}, cfg.Redaction.DenyList...), repoCfg.Redaction.DenyList...)
+	return redact.New(opts)
+}
@@ -2,10 +2,17 @@ package cli
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/config"
+	"github.com/brianndofor/prq/internal/prompt"
 	"github.com/spf13/cobra"
 )
 
 func NewConfigCmd() *cobra.Command {
+	var repo string
+
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Print merged configuration",
@@ -14,9 +21,41 @@ func NewConfigCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if repo != "" {
+				if !strings.Contains(repo, "/") {
+					return fmt.Errorf("invalid --repo %q: expected OWNER/REPO", repo)
+				}
+				sha, err := app.GH.HeadCommitSHA(cmd.Context(), repo)
+				if err != nil {
+					return err
+				}
+				if err := useRepoConfig(cmd.Context(), app, repo, sha, nil); err != nil {
+					return err
+				}
+			}
+			origins := make([]string, 0, len(app.RepoSources))
+			ignored := map[string][]string{}
+			for _, source := range app.RepoSources {
+				origins = append(origins, source.Origin)
+				keys, err := source.IgnoredKeys()
+				if err != nil {
+					return err
+				}
+				if len(keys) > 0 {
+					ignored[source.Origin] = keys
+				}
+			}
+			provenance, err := config.Provenance(app.RepoSources)
+			if err != nil {
+				return err
+			}
 			payload := map[string]any{
-				"user": app.Config,
-				"repo": app.RepoConfig,
+				"user":            app.Config,
+				"repo":            app.RepoConfig,
+				"repo_sources":    origins,
+				"repo_provenance": provenance,
+				"repo_ignored":    ignored,
+				"assets":          assetOrigins(),
 			}
 			data, err := json.MarshalIndent(payload, "", "  ")
 			if err != nil {
@@ -30,5 +69,22 @@ func NewConfigCmd() *cobra.Command {
 			return err
 		},
 	}
+
+	cmd.Flags().StringVar(&repo, "repo", "", "Merge the repo config committed to OWNER/REPO's default branch under ./prq.yaml")
 	return cmd
 }
+
+// assetOrigins maps each prompt template and schema to where it would be
+// loaded from, or to the error resolving it.
+func assetOrigins() map[string]string {
+	origins := map[string]string{}
+	for _, spec := range prompt.AssetSpecs {
+		asset, err := prompt.Resolve(spec)
+		if err != nil {
+			origins[spec.Name] = "error: " + err.Error()
+			continue
+		}
+		origins[spec.Name] = asset.Origin()
+	}
+	return origins
+}
@@ -9,20 +9,42 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func confirm(cmd *cobra.Command, prompt string) (bool, error) {
-	if _, err := fmt.Fprint(cmd.OutOrStdout(), prompt); err != nil {
-		return false, err
-	}
+// prompter reads answers from a single buffered reader so that several
+// questions in one command do not lose input to separate buffers.
+type prompter struct {
+	out io.Writer
+	in  *bufio.Reader
+}
 
-	reader := bufio.NewReader(cmd.InOrStdin())
-	line, err := reader.ReadString('\n')
+func newPrompter(cmd *cobra.Command) *prompter {
+	return &prompter{out: cmd.OutOrStdout(), in: bufio.NewReader(cmd.InOrStdin())}
+}
+
+// ask prints prompt and returns the trimmed answer. eof is true when input
+// is exhausted and nothing was typed.
+func (p *prompter) ask(prompt string) (answer string, eof bool, err error) {
+	if _, err := fmt.Fprint(p.out, prompt); err != nil {
+		return "", false, err
+	}
+	line, err := p.in.ReadString('\n')
 	if err != nil && err != io.EOF {
-		return false, err
+		return "", false, err
 	}
 	if err == io.EOF && strings.TrimSpace(line) == "" {
-		return false, nil
+		return "", true, nil
 	}
+	return strings.TrimSpace(line), false, nil
+}
 
-	resp := strings.ToLower(strings.TrimSpace(line))
+func (p *prompter) confirm(prompt string) (bool, error) {
+	answer, eof, err := p.ask(prompt)
+	if err != nil || eof {
+		return false, err
+	}
+	resp := strings.ToLower(answer)
 	return resp == "y" || resp == "yes", nil
 }
+
+func confirm(cmd *cobra.Command, prompt string) (bool, error) {
+	return newPrompter(cmd).confirm(prompt)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/config"
+	"github.com/brianndofor/prq/internal/diff"
+	"github.com/brianndofor/prq/internal/github"
+)
+
+// fileSource reads a file as it exists at a given revision.
+type fileSource interface {
+	ReadFile(ctx context.Context, path, ref string) (string, error)
+}
+
+// contentsSource reads files through the GitHub contents API.
+type contentsSource struct {
+	gh   *github.Client
+	repo string
+}
+
+func (s contentsSource) ReadFile(ctx context.Context, path, ref string) (string, error) {
+	return s.gh.FileContents(ctx, s.repo, path, ref)
+}
+
+// expandContext renders the code surrounding each hunk of diffText: the head
+// version of every added or modified file and the base version of modified
+// files. Files matching diff.ignore and binary files are skipped and
+// excerpts are chunked with diff.max_chunk_chars. Once the section reaches
+// context.max_chars, the remaining files are listed but not fetched. A file
+// that cannot be read, or is larger than context.max_file_bytes, is noted,
+// not fatal.
+func expandContext(ctx context.Context, app *App, src fileSource, diffText, baseRef, headRef string) (string, error) {
+	files, err := diff.ParseUnified(diffText)
+	if err != nil {
+		return "", err
+	}
+	cfg := app.RepoConfig.Context
+	var chunks []string
+	var omitted []string
+	total := 0
+	for _, file := range diff.ContextFiles(files, app.RepoConfig.Diff.Ignore, app.RepoConfig.Diff.MaxFiles) {
+		status := file.FileStatus()
+		if status == "removed" || file.IsBinary() {
+			continue
+		}
+		if cfg.MaxChars > 0 && total >= cfg.MaxChars {
+			omitted = append(omitted, file.Path)
+			continue
+		}
+		oldRanges, newRanges := diff.HunkRanges(file)
+		var b strings.Builder
+		b.WriteString(renderContextExcerpt(ctx, src, file.Path, "head", headRef, newRanges, cfg))
+		if status == "modified" && len(oldRanges) > 0 {
+			b.WriteString("\n")
+			b.WriteString(renderContextExcerpt(ctx, src, file.OldPath(), "base", baseRef, oldRanges, cfg))
+		}
+		for _, chunk := range diff.BuildContextChunks(file.Path, b.String(), app.RepoConfig.Diff.MaxChunkChars) {
+			if cfg.MaxChars > 0 && total+len(chunk) > cfg.MaxChars && total > 0 {
+				omitted = append(omitted, file.Path)
+				total = cfg.MaxChars
+				break
+			}
+			chunks = append(chunks, chunk)
+			total += len(chunk)
+		}
+	}
+	if len(omitted) > 0 {
+		chunks = append(chunks, fmt.Sprintf("(context for %d more file(s) omitted at context.max_chars: %s)", len(omitted), strings.Join(omitted, ", ")))
+	}
+	if len(chunks) == 0 {
+		return "None", nil
+	}
+	return strings.Join(chunks, "\n\n"), nil
+}
+
+func renderContextExcerpt(ctx context.Context, src fileSource, path, side, ref string, ranges []diff.LineRange, cfg config.ContextConfig) string {
+	label := fmt.Sprintf("%s %s", side, ref)
+	content, err := src.ReadFile(ctx, path, ref)
+	if err != nil {
+		return fmt.Sprintf("%s (%s)\n(unavailable: %v)\n", path, label, err)
+	}
+	if cfg.MaxFileBytes > 0 && len(content) > cfg.MaxFileBytes {
+		return fmt.Sprintf("%s (%s)\n(skipped: %d bytes exceeds context.max_file_bytes)\n", path, label, len(content))
+	}
+	if strings.ContainsRune(content[:min(len(content), 8000)], 0) {
+		return fmt.Sprintf("%s (%s)\n(skipped: binary file)\n", path, label)
+	}
+	expanded := diff.ExpandRanges(content, ranges, cfg.Lines, cfg.Enclosing)
+	return diff.RenderExcerpt(path, label, content, expanded)
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brianndofor/prq/internal/config"
+)
+
+type mapSource map[string]string
+
+func (m mapSource) ReadFile(ctx context.Context, path, ref string) (string, error) {
+	content, ok := m[ref+":"+path]
+	if !ok {
+		return "", fmt.Errorf("not found")
+	}
+	return content, nil
+}
+
+func TestExpandContext(t *testing.T) {
+	app := &App{RepoConfig: config.DefaultRepoConfig()}
+	app.RepoConfig.Diff.Ignore = []string{"*.md"}
+	app.RepoConfig.Context.Lines = 1
+	diffText := strings.Join([]string{
+		"diff --git a/auth.go b/auth.go",
+		"--- a/auth.go",
+		"+++ b/auth.go",
+		"@@ -3 +3 @@",
+		"-\treturn nil",
+		"+\treturn err",
+		"diff --git a/new.go b/new.go",
+		"new file mode 100644",
+		"--- /dev/null",
+		"+++ b/new.go",
+		"@@ -0,0 +1 @@",
+		"+package auth",
+		"diff --git a/README.md b/README.md",
+		"--- a/README.md",
+		"+++ b/README.md",
+		"@@ -1 +1 @@",
+		"-old",
+		"+new",
+		"",
+	}, "\n")
+	src := mapSource{
+		"head:auth.go": "package auth\nfunc Login() error {\n\treturn err\n}\n",
+		"base:auth.go": "package auth\nfunc Login() error {\n\treturn nil\n}\n",
+	}
+
+	out, err := expandContext(context.Background(), app, src, diffText, "base", "head")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"auth.go (head head)", "    3 | \treturn err", "auth.go (base base)", "    3 | \treturn nil", "new.go (head head)\n(unavailable: not found)"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "README.md") {
+		t.Fatalf("expected ignored files to be skipped:\n%s", out)
+	}
+	if strings.Contains(out, "new.go (base") {
+		t.Fatalf("expected no base excerpt for an added file:\n%s", out)
+	}
+}
+
+func TestExpandContextLimits(t *testing.T) {
+	app := &App{RepoConfig: config.DefaultRepoConfig()}
+	app.RepoConfig.Context.Lines = 1
+	app.RepoConfig.Context.MaxFileBytes = 100
+	app.RepoConfig.Context.MaxChars = 120
+	fileDiff := func(path string) string {
+		return "diff --git a/" + path + " b/" + path + "\nnew file mode 100644\n--- /dev/null\n+++ b/" + path + "\n@@ -0,0 +1 @@\n+x\n"
+	}
+	diffText := "diff --git a/logo.png b/logo.png\nnew file mode 100644\nBinary files /dev/null and b/logo.png differ\n" +
+		fileDiff("big.go") + fileDiff("a.go") + fileDiff("b.go") + fileDiff("c.go")
+	src := mapSource{
+		"head:big.go": strings.Repeat("x\n", 60),
+		"head:a.go":   "package a\n",
+		"head:b.go":   "package b\n",
+		"head:c.go":   "package c\n",
+	}
+	out, err := expandContext(context.Background(), app, src, diffText, "base", "head")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out, "logo.png") {
+		t.Fatalf("expected the binary file to be skipped:\n%s", out)
+	}
+	if !strings.Contains(out, "(skipped: 120 bytes exceeds context.max_file_bytes)") {
+		t.Fatalf("expected the large file to be skipped:\n%s", out)
+	}
+	if !strings.Contains(out, "omitted at context.max_chars: ") || strings.Contains(out, "package c") {
+		t.Fatalf("expected later files to be omitted once the cap is reached:\n%s", out)
+	}
+}
@@ -36,12 +36,30 @@ func NewDoctorCmd() *cobra.Command {
 			}
 			fmt.Fprintln(cmd.OutOrStdout(), "- provider: ok")
 
-			schema := prompt.DefaultSchemaPath()
-			if err := app.Provider.HealthCheck(ctx, schema); err != nil {
+			for _, spec := range prompt.AssetSpecs {
+				asset, err := prompt.Resolve(spec)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "- %s: %s\n", spec.Name, asset.Origin())
+			}
+
+			schema, err := prompt.LoadSchema()
+			if err != nil {
+				return err
+			}
+			if err := app.Provider.HealthCheck(ctx, schema.Content); err != nil {
 				fmt.Fprintf(cmd.OutOrStderr(), "- provider schema: failed\n%v\n", err)
 				return err
 			}
 			fmt.Fprintln(cmd.OutOrStdout(), "- provider schema: ok")
+
+			if app.RepoConfig.Tests.Sandbox == "bwrap" {
+				if _, err := exec.LookPath("bwrap"); err != nil {
+					return fmt.Errorf("tests.sandbox is bwrap but bubblewrap is not installed")
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "- test sandbox (bwrap): ok")
+			}
 			fmt.Fprintln(cmd.OutOrStdout(), "doctor checks passed")
 			return nil
 		},
@@ -1,15 +1,21 @@
 package cli
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 
+	"github.com/brianndofor/prq/internal/redact"
+	"github.com/brianndofor/prq/internal/store"
 	"github.com/spf13/cobra"
 )
 
 func NewDraftCmd() *cobra.Command {
 	var maxIssues int
 	var runTests bool
+	var sinceLast bool
+	var expandCtx bool
+	var profileName string
 
 	cmd := &cobra.Command{
 		Use:   "draft <pr-url|OWNER/REPO#123>",
@@ -21,34 +27,31 @@ func NewDraftCmd() *cobra.Command {
 				return err
 			}
 			ctx := cmd.Context()
-			run, err := generateReviewPlan(ctx, app, args[0], maxIssues, runTests)
+			run, err := generateReviewPlan(ctx, app, args[0], reviewOptions{maxIssues: maxIssues, runTests: runTests, sinceLast: sinceLast, context: expandCtx, profile: profileName, warn: cmd.ErrOrStderr()})
 			if err != nil {
 				return err
 			}
-			if err := app.Store.UpsertPR(run.FullRef, run.View.Repository.NameWithOwner, run.View.Number, run.View.HeadRefOid); err != nil {
-				return err
-			}
-			if err := app.Store.MarkReviewed(run.FullRef, run.View.HeadRefOid); err != nil {
+			_, preview, err := saveDraft(app, run)
+			if err != nil {
 				return err
 			}
 
-			payload := DraftReviewPayload{
-				Repo:    run.View.Repository.NameWithOwner,
-				Number:  run.View.Number,
-				BaseSHA: run.View.BaseRefOid,
-				HeadSHA: run.View.HeadRefOid,
-				Plan:    run.Plan,
+			if line := profileLine(run.Profile); line != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\n", line)
 			}
-			payloadJSON, err := json.Marshal(payload)
-			if err != nil {
-				return err
+			if run.OutOfProfile > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Dropped %d issue(s) outside the profile's categories.\n", run.OutOfProfile)
 			}
-			preview := renderDraftPreview(payload)
-			if err := app.Store.UpsertDraftReview(run.FullRef, string(payloadJSON), preview); err != nil {
-				return err
+			if run.Stale > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Dropped %d earlier issue(s) whose lines changed or left the diff.\n", run.Stale)
 			}
-
 			fmt.Fprint(cmd.OutOrStdout(), preview)
+			if len(run.Redactions) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "\nRedactions: %s\n", redact.Summary(run.Redactions))
+			}
+			if run.Scrubbed > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "WARNING: the model reproduced %d redacted value(s); they were replaced with their placeholders.\n", run.Scrubbed)
+			}
 			fmt.Fprintf(cmd.OutOrStdout(), "\nSaved locally. To post to GitHub, run: prq submit %s\n", run.FullRef)
 			return nil
 		},
@@ -56,5 +59,51 @@ func NewDraftCmd() *cobra.Command {
 
 	cmd.Flags().IntVar(&maxIssues, "max-issues", 0, "Limit issues count")
 	cmd.Flags().BoolVar(&runTests, "run-tests", false, "Run repo tests before drafting")
+	cmd.Flags().BoolVar(&sinceLast, "since-last", false, "Review only changes since the last reviewed head and merge into the previous draft")
+	cmd.Flags().BoolVar(&expandCtx, "context", false, "Include surrounding file contents for each hunk (overrides context.enabled)")
+	cmd.Flags().StringVar(&profileName, "profile", "", "Review profile to apply (default: chosen by auto_profiles; \"none\" disables them)")
 	return cmd
 }
+
+// saveDraft records the review against the PR and stores the draft in a single
+// transaction, so a concurrent prq process never sees one without the other.
+// Each save bumps the draft version; submitting deletes the draft and resets it.
+func saveDraft(app *App, run ReviewRun) (DraftReviewPayload, string, error) {
+	payload := DraftReviewPayload{
+		Repo:     run.View.Repository.NameWithOwner,
+		Number:   run.View.Number,
+		BaseSHA:  run.View.BaseRefOid,
+		HeadSHA:  run.View.HeadRefOid,
+		Plan:     run.Plan,
+		SinceSHA: run.SinceSHA,
+	}
+	var preview string
+	err := app.Store.InTx(func(tx *store.Store) error {
+		payload.Version = 1
+		if existing, err := tx.GetDraftReview(run.FullRef); err == nil {
+			var prev DraftReviewPayload
+			if json.Unmarshal([]byte(existing.PayloadJSON), &prev) == nil {
+				payload.Version = max(prev.Version, 1) + 1
+			}
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+		payloadJSON, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		preview = renderDraftPreview(payload)
+
+		if err := tx.UpsertPR(run.FullRef, run.View.Repository.NameWithOwner, run.View.Number, run.View.HeadRefOid); err != nil {
+			return err
+		}
+		if err := tx.MarkReviewed(run.FullRef, run.View.HeadRefOid); err != nil {
+			return err
+		}
+		return tx.UpsertDraftReview(run.FullRef, string(payloadJSON), preview)
+	})
+	if err != nil {
+		return DraftReviewPayload{}, "", err
+	}
+	return payload, preview, nil
+}
@@ -25,6 +25,12 @@ func renderDraftPreview(payload DraftReviewPayload) string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "PR: %s#%d\n", payload.Repo, payload.Number)
 	fmt.Fprintf(&b, "Head SHA: %s\n", payload.HeadSHA)
+	if payload.Version > 1 {
+		fmt.Fprintf(&b, "Draft version: %d\n", payload.Version)
+	}
+	if payload.SinceSHA != "" {
+		fmt.Fprintf(&b, "Incremental since: %s\n", payload.SinceSHA)
+	}
 	fmt.Fprintf(&b, "Event: %s\n\n", event)
 	b.WriteString("Review body:\n")
 	body := strings.TrimSpace(payload.Plan.DraftReviewBody)
@@ -49,6 +55,11 @@ func renderDraftPreview(payload DraftReviewPayload) string {
 }
 
 func renderIssueSummary(issue provider.Issue) string {
+	return fmt.Sprintf("%s [%s] %s", issueLocation(issue), issueTag(issue), issue.Message)
+}
+
+// issueLocation is "file", "file:line", or "file:start-end".
+func issueLocation(issue provider.Issue) string {
 	loc := issue.File
 	if issue.StartLine > 0 {
 		loc = fmt.Sprintf("%s:%d", issue.File, issue.StartLine)
@@ -56,12 +67,21 @@ func renderIssueSummary(issue provider.Issue) string {
 	if issue.EndLine > 0 && issue.EndLine != issue.StartLine {
 		loc = fmt.Sprintf("%s:%d-%d", issue.File, issue.StartLine, issue.EndLine)
 	}
-	return fmt.Sprintf("%s [%s/%s] %s", loc, issue.Severity, issue.Category, issue.Message)
+	return loc
+}
+
+// issueTag is "severity/category" for model issues and "tool: name" for
+// analyzer findings.
+func issueTag(issue provider.Issue) string {
+	if issue.Tool != "" {
+		return "tool: " + issue.Tool
+	}
+	return issue.Severity + "/" + issue.Category
 }
 
 func renderIssueCommentBody(issue provider.Issue) string {
 	var b strings.Builder
-	fmt.Fprintf(&b, "[%s/%s] %s", issue.Severity, issue.Category, issue.Message)
+	fmt.Fprintf(&b, "[%s] %s", issueTag(issue), issue.Message)
 	if strings.TrimSpace(issue.SuggestionPatch) != "" {
 		b.WriteString("\n\nSuggested patch:\n```diff\n")
 		b.WriteString(strings.TrimSpace(issue.SuggestionPatch))
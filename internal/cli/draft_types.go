@@ -8,4 +8,8 @@ type DraftReviewPayload struct {
 	BaseSHA string              `json:"base_sha"`
 	HeadSHA string              `json:"head_sha"`
 	Plan    provider.ReviewPlan `json:"plan"`
+	// Version counts drafts saved for the PR since the last submit.
+	Version int `json:"version,omitempty"`
+	// SinceSHA is set when the latest version only reviewed changes after it.
+	SinceSHA string `json:"since_sha,omitempty"`
 }
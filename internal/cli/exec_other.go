@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cli
+
+import "os/exec"
+
+// killProcessGroup falls back to killing only the direct child.
+func killProcessGroup(*exec.Cmd) {}
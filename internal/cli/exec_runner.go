@@ -1,16 +1,49 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type ExecRunner interface {
 	Run(ctx context.Context, dir string, name string, args ...string) (string, error)
+	// RunLimited runs an untrusted command with a fixed environment, a
+	// timeout, and a cap on captured output. A non-zero exit or timeout is
+	// reported in the result; the error is set only if the command could not
+	// be started.
+	RunLimited(ctx context.Context, dir string, opts ExecOptions, name string, args ...string) (ExecResult, error)
+}
+
+// ExecOptions restricts a command started by RunLimited.
+type ExecOptions struct {
+	// Env is the complete environment of the command.
+	Env []string
+	// Timeout kills the command (and its process group) when exceeded; zero
+	// means no limit.
+	Timeout time.Duration
+	// MaxOutputBytes caps captured stdout and stderr each; zero means no
+	// limit.
+	MaxOutputBytes int
+	// StdoutSink, if set, also receives the complete stdout stream, so it can
+	// be parsed without keeping it in memory.
+	StdoutSink io.Writer
+}
+
+// ExecResult is the outcome of RunLimited.
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+	TimedOut bool
 }
 
 type RealExecRunner struct{}
@@ -27,6 +60,77 @@ func (r RealExecRunner) Run(ctx context.Context, dir string, name string, args .
 	return string(output), nil
 }
 
+func (r RealExecRunner) RunLimited(ctx context.Context, dir string, opts ExecOptions, name string, args ...string) (ExecResult, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	if dir != "" {
+		cmd.Dir = dir
+	}
+	cmd.Env = opts.Env
+	killProcessGroup(cmd)
+	// Do not wait forever on pipes held open by orphaned children.
+	cmd.WaitDelay = 5 * time.Second
+	stdout := &cappedBuffer{limit: opts.MaxOutputBytes}
+	stderr := &cappedBuffer{limit: opts.MaxOutputBytes}
+	cmd.Stdout = stdout
+	if opts.StdoutSink != nil {
+		cmd.Stdout = io.MultiWriter(stdout, opts.StdoutSink)
+	}
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	result := ExecResult{Stdout: stdout.String(), Stderr: stderr.String(), Duration: time.Since(start)}
+	if ctx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+		result.ExitCode = -1
+		return result, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("command failed: %s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return result, nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and counts the rest.
+type cappedBuffer struct {
+	limit   int
+	buf     bytes.Buffer
+	dropped int
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if c.limit <= 0 {
+		return c.buf.Write(p)
+	}
+	room := c.limit - c.buf.Len()
+	if room < 0 {
+		room = 0
+	}
+	if len(p) > room {
+		c.buf.Write(p[:room])
+		c.dropped += len(p) - room
+		return len(p), nil
+	}
+	return c.buf.Write(p)
+}
+
+func (c *cappedBuffer) String() string {
+	if c.dropped == 0 {
+		return c.buf.String()
+	}
+	return fmt.Sprintf("%s\n... (output truncated: %d bytes dropped)\n", c.buf.String(), c.dropped)
+}
+
 type FakeExecRunner struct{}
 
 func (f FakeExecRunner) Run(ctx context.Context, dir string, name string, args ...string) (string, error) {
@@ -40,8 +144,27 @@ func (f FakeExecRunner) Run(ctx context.Context, dir string, name string, args .
 		}
 		return "cloned", nil
 	}
+	if name == "git" && len(args) >= 6 && args[2] == "worktree" && args[3] == "add" {
+		if err := os.MkdirAll(filepath.Clean(args[5]), 0o755); err != nil {
+			return "", err
+		}
+		return "", nil
+	}
 	if name == "gh" && len(args) >= 2 && args[0] == "pr" && args[1] == "checkout" {
 		return "checked out", nil
 	}
 	return "mock command output", nil
 }
+
+func (f FakeExecRunner) RunLimited(ctx context.Context, dir string, opts ExecOptions, name string, args ...string) (ExecResult, error) {
+	out, err := f.Run(ctx, dir, name, args...)
+	if err != nil {
+		return ExecResult{}, err
+	}
+	if opts.StdoutSink != nil {
+		_, _ = opts.StdoutSink.Write([]byte(out))
+	}
+	stdout := &cappedBuffer{limit: opts.MaxOutputBytes}
+	_, _ = stdout.Write([]byte(out))
+	return ExecResult{Stdout: stdout.String()}, nil
+}
@@ -3,13 +3,26 @@ package cli
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/brianndofor/prq/internal/github"
 	"github.com/spf13/cobra"
 )
 
+// threadHunkLines is how many trailing diff hunk lines are shown for a
+// thread, matching what GitHub shows above a review comment.
+const threadHunkLines = 4
+
 func NewFollowupCmd() *cobra.Command {
+	var reply bool
+	var resolve bool
+	var unresolve bool
+	var verify bool
+	var yes bool
+	var dryRun bool
+	var allowSensitive bool
+
 	cmd := &cobra.Command{
 		Use:   "followup <pr-url|OWNER/REPO#123>",
 		Short: "Show open review threads and changes since last review",
@@ -19,6 +32,12 @@ func NewFollowupCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if countTrue(reply, resolve, unresolve, verify) > 1 {
+				return fmt.Errorf("--reply, --resolve, --unresolve, and --verify are mutually exclusive")
+			}
+			if verify && yes {
+				return fmt.Errorf("--yes is not supported with --verify; model-suggested actions are always confirmed")
+			}
 
 			repo, number, err := github.ParsePR(args[0])
 			if err != nil {
@@ -31,6 +50,9 @@ func NewFollowupCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if err := useRepoConfig(ctx, app, repo, view.BaseRefOid, cmd.ErrOrStderr()); err != nil {
+				return err
+			}
 			if err := app.Store.UpsertPR(fullRef, view.Repository.NameWithOwner, view.Number, view.HeadRefOid); err != nil {
 				return err
 			}
@@ -58,14 +80,21 @@ func NewFollowupCmd() *cobra.Command {
 				fmt.Fprintln(cmd.OutOrStdout(), "No previous review recorded.")
 			}
 
+			// changed stays nil when there is no earlier review to compare against.
+			var changed []github.CompareFile
 			if state.LastReviewedHeadSHA.Valid {
 				if state.LastReviewedHeadSHA.String == view.HeadRefOid {
+					changed = []github.CompareFile{}
 					fmt.Fprintln(cmd.OutOrStdout(), "No new commits since last review.")
 				} else {
 					compare, err := app.GH.CompareCommits(ctx, repo, state.LastReviewedHeadSHA.String, view.HeadRefOid)
 					if err != nil {
 						return err
 					}
+					changed = compare.Files
+					if changed == nil {
+						changed = []github.CompareFile{}
+					}
 					fmt.Fprintf(cmd.OutOrStdout(), "Changes since last review: %d commits\n", compare.TotalCommits)
 					for _, file := range compare.Files {
 						fmt.Fprintf(cmd.OutOrStdout(), "- %s (%s +%d/-%d)\n", file.Filename, file.Status, file.Additions, file.Deletions)
@@ -73,37 +102,85 @@ func NewFollowupCmd() *cobra.Command {
 				}
 			}
 
+			if resolve || unresolve {
+				return runFollowupResolve(cmd, app, fullRef, threads, changed, unresolve, yes, dryRun)
+			}
 			if len(openThreads) == 0 {
 				fmt.Fprintln(cmd.OutOrStdout(), "No open review threads.")
 				return nil
 			}
+			if reply {
+				return runFollowupReply(cmd, app, openThreads, yes, dryRun, allowSensitive)
+			}
+			if verify {
+				return runFollowupVerify(cmd, app, view, state.LastReviewedHeadSHA.String, openThreads, changed, dryRun, allowSensitive)
+			}
 			fmt.Fprintf(cmd.OutOrStdout(), "Open review threads: %d\n", len(openThreads))
 			for _, thread := range openThreads {
-				lineLabel := "?"
-				if thread.Line != nil {
-					lineLabel = fmt.Sprintf("%d", *thread.Line)
-				}
-				path := thread.Path
-				if strings.TrimSpace(path) == "" {
-					path = "(unknown)"
-				}
-				count := thread.Count
-				if count == 0 {
-					count = len(thread.Comments)
-				}
-				fmt.Fprintf(cmd.OutOrStdout(), "- %s:%s (%d comments)", path, lineLabel, count)
-				if thread.IsOutdated {
-					fmt.Fprint(cmd.OutOrStdout(), " [outdated]")
-				}
-				fmt.Fprintln(cmd.OutOrStdout())
-				if len(thread.Comments) > 0 {
-					last := thread.Comments[len(thread.Comments)-1]
-					fmt.Fprintf(cmd.OutOrStdout(), "  Last: %s at %s\n", last.Author, last.CreatedAt)
-				}
+				writeThread(cmd.OutOrStdout(), thread)
 			}
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&reply, "reply", false, "Walk open threads and post replies")
+	cmd.Flags().BoolVar(&resolve, "resolve", false, "Resolve open threads you started, after confirming")
+	cmd.Flags().BoolVar(&unresolve, "unresolve", false, "Reopen resolved threads you started, after confirming")
+	cmd.Flags().BoolVar(&verify, "verify", false, "Ask the provider whether new commits address each open thread")
+	cmd.Flags().BoolVar(&yes, "yes", false, "Skip confirmation prompts")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview only; do not post")
+	cmd.Flags().BoolVar(&allowSensitive, "allow-sensitive", false, "Post replies even if they match a redaction rule or deny-list phrase")
 	return cmd
 }
+
+func threadLocation(thread github.ReviewThread) string {
+	lineLabel := "?"
+	if thread.Line != nil {
+		lineLabel = fmt.Sprintf("%d", *thread.Line)
+	}
+	path := thread.Path
+	if strings.TrimSpace(path) == "" {
+		path = "(unknown)"
+	}
+	return path + ":" + lineLabel
+}
+
+func writeThread(out io.Writer, thread github.ReviewThread) {
+	count := thread.Count
+	if count == 0 {
+		count = len(thread.Comments)
+	}
+	fmt.Fprintf(out, "- %s (%d comments)", threadLocation(thread), count)
+	if thread.IsOutdated {
+		fmt.Fprint(out, " [outdated]")
+	}
+	fmt.Fprintln(out)
+	if hunk := strings.TrimRight(thread.DiffHunk, "\n"); hunk != "" {
+		lines := strings.Split(hunk, "\n")
+		if len(lines) > threadHunkLines {
+			lines = lines[len(lines)-threadHunkLines:]
+		}
+		for _, line := range lines {
+			fmt.Fprintf(out, "    | %s\n", line)
+		}
+	}
+	for _, comment := range thread.Comments {
+		fmt.Fprintf(out, "  %s at %s:\n", comment.Author, comment.CreatedAt)
+		for _, line := range strings.Split(strings.TrimSpace(comment.Body), "\n") {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+	if count > len(thread.Comments) {
+		fmt.Fprintf(out, "  (%d more comments not shown)\n", count-len(thread.Comments))
+	}
+}
+
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
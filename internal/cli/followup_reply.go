@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/spf13/cobra"
+)
+
+// runFollowupReply walks open threads and posts replies typed by the user.
+func runFollowupReply(cmd *cobra.Command, app *App, threads []github.ReviewThread, yes bool, dryRun bool, allowSensitive bool) error {
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+	posted := 0
+	for i, thread := range threads {
+		fmt.Fprintf(out, "\nThread %d/%d\n", i+1, len(threads))
+		writeThread(out, thread)
+
+		text, eof, err := p.ask("Reply (blank to skip, q to stop): ")
+		if err != nil {
+			return err
+		}
+		if eof || text == "q" {
+			break
+		}
+		if text == "" {
+			continue
+		}
+		ok, err := postThreadReply(cmd, app, p, thread, text, yes, dryRun, allowSensitive)
+		if err != nil {
+			return err
+		}
+		if ok {
+			posted++
+		}
+	}
+	fmt.Fprintf(out, "\nPosted %d replies.\n", posted)
+	return nil
+}
+
+// postThreadReply redacts, scans, and previews a reply, then posts it after
+// confirmation, like `prq submit`: a reply matching a redaction rule or
+// deny-list phrase is only posted with allowSensitive. It reports whether
+// the reply was posted.
+func postThreadReply(cmd *cobra.Command, app *App, p *prompter, thread github.ReviewThread, text string, yes bool, dryRun bool, allowSensitive bool) (bool, error) {
+	out := cmd.OutOrStdout()
+	body := app.Redactor.RedactOptional(text, app.Config.Redaction.Enabled)
+	if body != text {
+		fmt.Fprintln(out, "WARNING: reply contained values that look like secrets; they were redacted.")
+	}
+	var hits []outboundHit
+	for _, f := range app.Redactor.Scan(body) {
+		hits = append(hits, outboundHit{Where: "reply", Finding: f})
+	}
+	for _, hit := range hits {
+		fmt.Fprintf(out, "SENSITIVE: %s\n", hit)
+	}
+	if len(hits) > 0 {
+		fmt.Fprintln(out, "Posting requires --allow-sensitive.")
+	}
+	fmt.Fprintf(out, "Reply preview:\n%s\n", body)
+	if dryRun {
+		fmt.Fprintln(out, "DRY RUN: not posting to GitHub.")
+		return false, nil
+	}
+	if len(hits) > 0 && !allowSensitive {
+		fmt.Fprintf(out, "Not posted: reply contains %d sensitive match(es); edit it or rerun with --allow-sensitive to post it anyway.\n", len(hits))
+		return false, nil
+	}
+	if !yes {
+		ok, err := p.confirm("Post this reply? [y/N]: ")
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Skipped.")
+			return false, nil
+		}
+	}
+	comment, err := app.GH.ReplyToThread(cmd.Context(), thread.ID, body)
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(comment.URL) != "" {
+		fmt.Fprintf(out, "Posted reply: %s\n", comment.URL)
+	} else {
+		fmt.Fprintln(out, "Posted reply.")
+	}
+	return true, nil
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/spf13/cobra"
+)
+
+// runFollowupResolve lists threads started by the current user, shows how
+// their files changed since the last review, and resolves (or, with reopen,
+// unresolves) the selected threads after confirmation. changed is nil when
+// there is no earlier review to compare against.
+func runFollowupResolve(cmd *cobra.Command, app *App, fullRef string, threads []github.ReviewThread, changed []github.CompareFile, reopen bool, yes bool, dryRun bool) error {
+	out := cmd.OutOrStdout()
+	ctx := cmd.Context()
+	verb, state := "Resolve", "open"
+	if reopen {
+		verb, state = "Unresolve", "resolved"
+	}
+
+	me, err := app.GH.CurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+	candidates := []github.ReviewThread{}
+	for _, thread := range threads {
+		if thread.IsResolved == reopen && threadAuthor(thread) == me {
+			candidates = append(candidates, thread)
+		}
+	}
+	if len(candidates) == 0 {
+		fmt.Fprintf(out, "No %s review threads started by %s.\n", state, me)
+		return nil
+	}
+
+	changedByPath := map[string]github.CompareFile{}
+	for _, file := range changed {
+		changedByPath[file.Filename] = file
+	}
+	fmt.Fprintf(out, "%s%s review threads started by %s: %d\n", strings.ToUpper(state[:1]), state[1:], me, len(candidates))
+	for i, thread := range candidates {
+		fmt.Fprintf(out, "\n[%d]\n", i+1)
+		writeThread(out, thread)
+		file, ok := changedByPath[thread.Path]
+		switch {
+		case changed == nil:
+			fmt.Fprintln(out, "  No previous review recorded; cannot tell what changed.")
+		case ok:
+			fmt.Fprintf(out, "  Changed since last review: %s +%d/-%d\n", file.Status, file.Additions, file.Deletions)
+		default:
+			fmt.Fprintln(out, "  File unchanged since last review.")
+		}
+	}
+
+	p := newPrompter(cmd)
+	answer, eof, err := p.ask(fmt.Sprintf("\n%s which threads? [a]ll, numbers like 1,3, or blank for none: ", verb))
+	if err != nil {
+		return err
+	}
+	if eof || answer == "" {
+		fmt.Fprintln(out, "Nothing selected.")
+		return nil
+	}
+	selected, err := parseThreadSelection(answer, len(candidates))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Selected %d threads:\n", len(selected))
+	for _, idx := range selected {
+		fmt.Fprintf(out, "- %s\n", threadLocation(candidates[idx]))
+	}
+	if dryRun {
+		fmt.Fprintln(out, "DRY RUN: not changing threads on GitHub.")
+		return nil
+	}
+	if !yes {
+		ok, err := p.confirm(fmt.Sprintf("%s %d threads on %s? [y/N]: ", verb, len(selected), fullRef))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Aborted.")
+			return nil
+		}
+	}
+
+	for _, idx := range selected {
+		thread := candidates[idx]
+		if reopen {
+			err = app.GH.UnresolveThread(ctx, thread.ID)
+		} else {
+			err = app.GH.ResolveThread(ctx, thread.ID)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%sd %s\n", verb, threadLocation(thread))
+	}
+	return nil
+}
+
+// threadAuthor is the login of whoever left the first comment on the thread.
+func threadAuthor(thread github.ReviewThread) string {
+	if len(thread.Comments) == 0 {
+		return ""
+	}
+	return thread.Comments[0].Author
+}
+
+// parseThreadSelection turns "a", "all", or a comma-separated list of
+// 1-based numbers into sorted, de-duplicated 0-based indexes.
+func parseThreadSelection(answer string, n int) ([]int, error) {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "a" || answer == "all" {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+	seen := map[int]bool{}
+	selected := []int{}
+	for _, part := range strings.Split(answer, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		num, err := strconv.Atoi(part)
+		if err != nil || num < 1 || num > n {
+			return nil, fmt.Errorf("invalid thread selection %q; expected numbers between 1 and %d", part, n)
+		}
+		if !seen[num-1] {
+			seen[num-1] = true
+			selected = append(selected, num-1)
+		}
+	}
+	sort.Ints(selected)
+	return selected, nil
+}
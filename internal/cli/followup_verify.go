@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/prompt"
+	"github.com/brianndofor/prq/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+// runFollowupVerify asks the provider whether the commits since the last
+// review address each open thread, then offers to resolve addressed threads
+// or post the suggested replies. Every action is confirmed individually.
+func runFollowupVerify(cmd *cobra.Command, app *App, view github.PRView, lastReviewedSHA string, threads []github.ReviewThread, changed []github.CompareFile, dryRun bool, allowSensitive bool) error {
+	out := cmd.OutOrStdout()
+	ctx := cmd.Context()
+	if changed == nil {
+		return fmt.Errorf("no previous review recorded; --verify needs a reviewed head to compare against")
+	}
+	if len(changed) == 0 {
+		fmt.Fprintln(out, "No changes since last review; nothing to verify.")
+		return nil
+	}
+
+	diffText, err := interdiffChunks(app, changed)
+	if err != nil {
+		return err
+	}
+	enabled := app.Config.Redaction.Enabled
+	template, err := prompt.LoadFollowupTemplate()
+	if err != nil {
+		return err
+	}
+	promptText, err := prompt.RenderFollowup(string(template.Content), prompt.FollowupSnapshot{
+		Repo:            view.Repository.NameWithOwner,
+		PRNumber:        view.Number,
+		LastReviewedSHA: lastReviewedSHA,
+		HeadSHA:         view.HeadRefOid,
+		Threads:         app.Redactor.RedactOptional(renderThreadsForPrompt(threads), enabled),
+		IncrementalDiff: app.Redactor.RedactOptional(diffText, enabled),
+	})
+	if err != nil {
+		return err
+	}
+	promptText = app.Redactor.RedactPromptBlock(promptText, enabled)
+
+	schema, err := prompt.LoadFollowupSchema()
+	if err != nil {
+		return err
+	}
+	check, _, err := app.Provider.RunFollowupCheck(ctx, promptText, schema.Content)
+	if err != nil {
+		return err
+	}
+	verdicts := map[string]provider.ThreadVerdict{}
+	for _, verdict := range check.Verdicts {
+		verdicts[verdict.ThreadID] = verdict
+	}
+
+	fmt.Fprintf(out, "Verification of %d open review threads:\n", len(threads))
+	p := newPrompter(cmd)
+	for i, thread := range threads {
+		verdict, ok := verdicts[thread.ID]
+		if !ok {
+			fmt.Fprintf(out, "\n[%d] %s: no verdict returned\n", i+1, threadLocation(thread))
+			continue
+		}
+		fmt.Fprintf(out, "\n[%d] %s: %s\n", i+1, threadLocation(thread), verdict.Status)
+		fmt.Fprintf(out, "  %s\n", strings.TrimSpace(verdict.Rationale))
+
+		if verdict.Status == provider.FollowupAddressed {
+			if dryRun {
+				fmt.Fprintln(out, "DRY RUN: not resolving on GitHub.")
+				continue
+			}
+			ok, err := p.confirm("Resolve this thread? [y/N]: ")
+			if err != nil {
+				return err
+			}
+			if ok {
+				if err := app.GH.ResolveThread(ctx, thread.ID); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Resolved %s\n", threadLocation(thread))
+				continue
+			}
+		}
+		if strings.TrimSpace(verdict.SuggestedReply) != "" {
+			if _, err := postThreadReply(cmd, app, p, thread, strings.TrimSpace(verdict.SuggestedReply), false, dryRun, allowSensitive); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func renderThreadsForPrompt(threads []github.ReviewThread) string {
+	var b strings.Builder
+	for i, thread := range threads {
+		fmt.Fprintf(&b, "Thread %d\nThread id: %s\nLocation: %s", i+1, thread.ID, threadLocation(thread))
+		if thread.IsOutdated {
+			b.WriteString(" (outdated)")
+		}
+		b.WriteString("\n")
+		if len(thread.Comments) > 0 {
+			first := thread.Comments[0]
+			fmt.Fprintf(&b, "Original comment by %s:\n%s\n", first.Author, strings.TrimSpace(first.Body))
+			if len(thread.Comments) > 1 {
+				b.WriteString("Replies:\n")
+				for _, comment := range thread.Comments[1:] {
+					fmt.Fprintf(&b, "- %s: %s\n", comment.Author, strings.TrimSpace(comment.Body))
+				}
+			}
+		}
+		if hunk := strings.TrimSpace(thread.DiffHunk); hunk != "" {
+			fmt.Fprintf(&b, "Code when the comment was left:\n%s\n", hunk)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
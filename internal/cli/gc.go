@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func NewGCCmd() *cobra.Command {
+	var maxAge string
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove PR worktrees not used recently and mirrors of untracked repos",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd.Context())
+			if err != nil {
+				return err
+			}
+			age, err := mirrorMaxAge(app)
+			if err != nil {
+				return err
+			}
+			if maxAge != "" {
+				age, err = time.ParseDuration(maxAge)
+				if err != nil {
+					return fmt.Errorf("invalid --max-age %q: %w", maxAge, err)
+				}
+			}
+			removed, err := gcWorktrees(cmd.Context(), app, mirrorRoot(app), age, time.Now())
+			for _, dir := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", dir)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d worktree(s) unused for %s.\n", len(removed), age)
+
+			tracked, err := app.Store.TrackedRepos()
+			if err != nil {
+				return err
+			}
+			mirrors, err := gcMirrors(mirrorRoot(app), tracked)
+			for _, dir := range mirrors {
+				fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", dir)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d mirror(s) with no tracked PRs.\n", len(mirrors))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&maxAge, "max-age", "", "Remove worktrees unused for this long, e.g. 72h (default mirrors.max_age)")
+	return cmd
+}
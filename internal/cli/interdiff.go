@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/diff"
+	"github.com/brianndofor/prq/internal/github"
+)
+
+// compareUnifiedDiff rebuilds a unified diff from the per-file patches of a
+// compare response so it can go through the same parsing and chunking as a
+// PR diff. GitHub omits the patch for binary and very large files; those are
+// kept with a note so the model knows they changed.
+func compareUnifiedDiff(files []github.CompareFile) string {
+	var b strings.Builder
+	for _, file := range files {
+		oldPath := file.Filename
+		if file.PreviousFilename != "" {
+			oldPath = file.PreviousFilename
+		}
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", oldPath, file.Filename)
+		if file.Patch == "" {
+			fmt.Fprintf(&b, "(%s; patch unavailable)\n", file.Status)
+			continue
+		}
+		fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", oldPath, file.Filename)
+		b.WriteString(file.Patch)
+		if !strings.HasSuffix(file.Patch, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// interdiffChunks chunks the changes between two heads using the repo's diff
+// limits and ignore globs.
+func interdiffChunks(app *App, files []github.CompareFile) (string, error) {
+	parsed, err := diff.ParseUnified(compareUnifiedDiff(files))
+	if err != nil {
+		return "", err
+	}
+	chunks, err := diff.BuildChunks(parsed, app.RepoConfig.Diff.Ignore, app.RepoConfig.Diff.MaxFiles, app.RepoConfig.Diff.MaxChunkChars)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(chunks, "\n\n"), nil
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile only creates path; without flock, concurrent runs on the same PR
+// are not serialized.
+func lockFile(path string, wait bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock %s: %w", path, err)
+	}
+	return func() { f.Close() }, nil
+}
@@ -0,0 +1,45 @@
+//go:build unix
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on path, creating it if needed.
+// With wait false it returns errLocked instead of blocking when another
+// process holds the lock. The returned func releases it. A lock whose file
+// was removed while waiting is retried on the new file, so holders may
+// delete the lock file before releasing it.
+func lockFile(path string, wait bool) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock %s: %w", path, err)
+		}
+		how := syscall.LOCK_EX
+		if !wait {
+			how |= syscall.LOCK_NB
+		}
+		if err := syscall.Flock(int(f.Fd()), how); err != nil {
+			f.Close()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, errLocked
+			}
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		held, err := f.Stat()
+		current, statErr := os.Stat(path)
+		if err != nil || statErr != nil || !os.SameFile(held, current) {
+			f.Close()
+			continue
+		}
+		return func() {
+			_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+			f.Close()
+		}, nil
+	}
+}
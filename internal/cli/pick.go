@@ -4,19 +4,19 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/brianndofor/prq/internal/github"
 	"github.com/spf13/cobra"
 )
 
 type pickOptions struct {
-	limit  int
-	repo   string
-	owner  string
-	label  string
-	checks string
-	draft  string
-	sortBy string
-	mine   bool
+	limit        int
+	repo         string
+	owner        string
+	label        string
+	checks       string
+	reviewStatus string
+	draft        string
+	sortBy       string
+	mine         bool
 }
 
 func NewPickCmd() *cobra.Command {
@@ -39,8 +39,9 @@ func NewPickCmd() *cobra.Command {
 	cmd.Flags().StringVar(&opts.owner, "owner", "", "Filter by org/owner")
 	cmd.Flags().StringVar(&opts.label, "label", "", "Filter by label")
 	cmd.Flags().StringVar(&opts.checks, "checks", "any", "Filter by checks: failure|pending|success|any")
+	cmd.Flags().StringVar(&opts.reviewStatus, "review-status", "any", "Filter by review status: never-reviewed|reviewed-up-to-date|new-commits-since-review|draft-pending-submit|any")
 	cmd.Flags().StringVar(&opts.draft, "draft", "any", "Filter by draft: true|false|any")
-	cmd.Flags().StringVar(&opts.sortBy, "sort", "", "Sort: oldest|updated|ci|size")
+	cmd.Flags().StringVar(&opts.sortBy, "sort", "", "Sort: oldest|updated|ci|size|status (default queue.default_sort, else status)")
 	cmd.Flags().BoolVar(&opts.mine, "mine", false, "Show my authored PRs instead of review requests")
 
 	return cmd
@@ -50,40 +51,19 @@ func runPicker(cmd *cobra.Command, app *App, opts pickOptions) error {
 	if !app.Config.TUI.Enabled {
 		return fmt.Errorf("tui picker is disabled in config")
 	}
-	if opts.limit == 0 {
-		opts.limit = app.Config.Queue.DefaultLimit
-	}
 	if opts.sortBy == "" {
 		opts.sortBy = app.Config.Queue.DefaultSort
 	}
-
-	query := buildQueueQuery(opts.repo, opts.owner, opts.label, opts.draft)
-	ghSort, order := mapSort(opts.sortBy)
-	mode := github.SearchModeReviewRequested
-	if opts.mine {
-		mode = github.SearchModeMine
+	if opts.sortBy == "" {
+		// Without a configured default, surface PRs the author pushed to
+		// after our review first.
+		opts.sortBy = "status"
 	}
-	items, err := app.GH.SearchPRs(cmd.Context(), query, opts.limit, ghSort, order, mode)
+	res, err := loadQueue(cmd.Context(), app, opts)
 	if err != nil {
 		return err
 	}
-	queue := buildQueueItems(items)
-	if opts.checks != "any" || opts.sortBy == "ci" {
-		queue, err = applyChecks(cmd.Context(), app.GH, queue, opts.checks)
-		if err != nil {
-			return err
-		}
-	}
-	if opts.sortBy == "size" {
-		queue, err = applySizes(cmd.Context(), app.GH, queue)
-		if err != nil {
-			return err
-		}
-	}
-	if opts.checks != "any" {
-		queue = filterByChecks(queue, opts.checks)
-	}
-	sortQueue(queue, opts.sortBy)
+	queue := res.Items
 
 	if len(queue) == 0 {
 		fmt.Fprintln(cmd.OutOrStdout(), "No PRs found.")
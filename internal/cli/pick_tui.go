@@ -38,11 +38,11 @@ type listItem struct {
 }
 
 func (i listItem) Title() string {
-	return fmt.Sprintf("%s#%d %s", i.item.Repo, i.item.Number, i.item.Title)
+	return fmt.Sprintf("%s%s#%d %s", deltaPrefix(i.item.Delta), i.item.Repo, i.item.Number, i.item.Title)
 }
 
 func (i listItem) Description() string {
-	return fmt.Sprintf("Author: %s  Age: %dd  Updated: %dd  Draft: %v  Checks: %s", i.item.Author, i.item.AgeDays, i.item.UpdatedDays, i.item.IsDraft, i.item.Checks)
+	return fmt.Sprintf("Author: %s  Age: %dd  Updated: %dd  Draft: %v  Checks: %s  Review: %s", i.item.Author, i.item.AgeDays, i.item.UpdatedDays, i.item.IsDraft, i.item.Checks, i.item.ReviewStatus)
 }
 
 func (i listItem) FilterValue() string {
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/config"
+	"github.com/brianndofor/prq/internal/diff"
+	"github.com/brianndofor/prq/internal/prompt"
+	"github.com/brianndofor/prq/internal/provider"
+)
+
+// noProfile disables auto_profiles for a run.
+const noProfile = "none"
+
+// reviewProfile is the profile applied to one review run.
+type reviewProfile struct {
+	Name string
+	// Reason says how the profile was chosen, e.g. "--profile" or
+	// "auto_profiles: internal/auth/** matched internal/auth/auth.go".
+	Reason string
+	config.ProfileConfig
+}
+
+// selectProfile returns the profile named by --profile or, when name is
+// empty, the first auto_profiles entry matching one of the changed paths.
+// It returns nil when no profile applies.
+func selectProfile(app *App, name string, paths []string) (*reviewProfile, error) {
+	profiles := config.MergeProfiles(app.Config, app.RepoConfig)
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case noProfile:
+		return nil, nil
+	case "":
+		for _, selector := range app.RepoConfig.AutoProfiles {
+			pattern, path, ok := diff.MatchAnyGlob(selector.Paths, paths)
+			if !ok {
+				continue
+			}
+			reason := fmt.Sprintf("auto_profiles: %s matched %s", pattern, path)
+			return &reviewProfile{Name: selector.Profile, Reason: reason, ProfileConfig: profiles[selector.Profile]}, nil
+		}
+		return nil, nil
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		known := make([]string, 0, len(profiles))
+		for n := range profiles {
+			known = append(known, n)
+		}
+		sort.Strings(known)
+		if len(known) == 0 {
+			return nil, fmt.Errorf("unknown profile %q; no profiles are configured", name)
+		}
+		return nil, fmt.Errorf("unknown profile %q; configured profiles: %s", name, strings.Join(known, ", "))
+	}
+	return &reviewProfile{Name: name, Reason: "--profile", ProfileConfig: profile}, nil
+}
+
+// profileAsset reads a profile's prompt or schema file from repo at sha, the
+// PR's base commit, so a PR cannot change how it is reviewed.
+func profileAsset(ctx context.Context, app *App, repo, sha string, spec prompt.AssetSpec, file string) (prompt.Asset, error) {
+	clean, err := config.RepoPath(file)
+	if err != nil {
+		return prompt.Asset{}, fmt.Errorf("profile %s: %w", spec.Name, err)
+	}
+	content, err := app.GH.FileContents(ctx, repo, clean, sha)
+	if err != nil {
+		return prompt.Asset{}, fmt.Errorf("failed to read profile %s %s: %w", spec.Name, clean, err)
+	}
+	return prompt.ProfileAsset(spec, fmt.Sprintf("%s@%s:%s", repo, sha, clean), []byte(content)), nil
+}
+
+// filterCategories keeps the issues in one of categories, or all of them
+// when categories is empty, and returns how many were dropped.
+func filterCategories(issues []provider.Issue, categories []string) ([]provider.Issue, int) {
+	if len(categories) == 0 {
+		return issues, 0
+	}
+	allowed := map[string]bool{}
+	for _, c := range categories {
+		allowed[strings.ToLower(c)] = true
+	}
+	kept := make([]provider.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if allowed[strings.ToLower(issue.Category)] {
+			kept = append(kept, issue)
+		}
+	}
+	return kept, len(issues) - len(kept)
+}
+
+// profileLine describes the profile for command output, or "" for none.
+func profileLine(profile *reviewProfile) string {
+	if profile == nil {
+		return ""
+	}
+	return fmt.Sprintf("Profile: %s (%s)", profile.Name, profile.Reason)
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReviewProfileAutoSelect(t *testing.T) {
+	cleanup := withMockEnv(t)
+	defer cleanup()
+
+	userConfig := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(userConfig, []byte(`profiles:
+  security:
+    rules:
+      - Check every auth path for missing authorization.
+    categories: [security]
+  docs:
+    categories: [docs]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(repoDir, "prq.yaml"), []byte(`auto_profiles:
+  - profile: Security
+    paths: ["internal/auth/**"]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(repoDir)
+
+	output := runRoot(t, "review", "acme/app#42", "--config", userConfig)
+	if !strings.Contains(output, "Profile: security (auto_profiles: internal/auth/** matched internal/auth/auth.go)") {
+		t.Fatalf("expected the security profile to be selected, got:\n%s", output)
+	}
+	// The fixture's only issue is a readability issue.
+	if !strings.Contains(output, "Dropped 1 issue(s) outside the profile's categories.") {
+		t.Fatalf("expected the readability issue to be dropped, got:\n%s", output)
+	}
+
+	output = runRoot(t, "redact", "--dry-run", "acme/app#42", "--config", userConfig)
+	if !strings.Contains(output, "Focus of this security review (report only issues within this focus)\n- Check every auth path") {
+		t.Fatalf("expected the profile rules in the prompt, got:\n%s", output)
+	}
+
+	output = runRoot(t, "review", "acme/app#42", "--config", userConfig, "--profile", "none")
+	if strings.Contains(output, "Profile:") {
+		t.Fatalf("expected --profile none to disable auto_profiles, got:\n%s", output)
+	}
+
+	cmd := NewRootCmd()
+	cmd.SetOut(&strings.Builder{})
+	cmd.SetErr(&strings.Builder{})
+	cmd.SetArgs([]string{"review", "acme/app#42", "--config", userConfig, "--profile", "perf"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "configured profiles: docs, security") {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+}
+
+func TestReviewProfileAssetsFromBase(t *testing.T) {
+	cleanup := withMockEnv(t)
+	defer cleanup()
+
+	// Serve a copy of the fixtures with a profile prompt on the base branch.
+	fixtures := t.TempDir()
+	if err := os.CopyFS(fixtures, os.DirFS(os.Getenv("PRQ_MOCK_DIR"))); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PRQ_MOCK_DIR", fixtures)
+	template := filepath.Join(fixtures, "contents", ".prq", "security.txt")
+	if err := os.MkdirAll(filepath.Dir(template), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(template, []byte("Security template from the base branch.\n{{.DiffChunks}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	writeConfig := func(body string) string {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	userConfig := writeConfig("profiles:\n  security:\n    prompt: .prq/security.txt\n")
+	output := runRoot(t, "redact", "--dry-run", "acme/app#42", "--config", userConfig, "--profile", "security")
+	if !strings.Contains(output, "Security template from the base branch.") {
+		t.Fatalf("expected the profile prompt from the base branch, got:\n%s", output)
+	}
+
+	for _, tc := range []struct{ config, prq, want string }{
+		{config: "profiles:\n  security:\n    prompt: /etc/passwd\n", want: "must be relative to the repo root"},
+		{config: "profiles:\n  security:\n    schema: ../secrets.json\n", want: "must not contain .."},
+		{prq: "profiles:\n  security:\n    provider_args: [\"--dangerously-skip-permissions\"]\n", want: "provider_args can only be set in the user config"},
+	} {
+		dir := t.TempDir()
+		if tc.prq != "" {
+			if err := os.WriteFile(filepath.Join(dir, "prq.yaml"), []byte(tc.prq), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		t.Chdir(dir)
+		cmd := NewRootCmd()
+		cmd.SetOut(&strings.Builder{})
+		cmd.SetErr(&strings.Builder{})
+		cmd.SetArgs([]string{"redact", "--dry-run", "acme/app#42", "--config", writeConfig(tc.config)})
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("expected an error containing %q, got %v", tc.want, err)
+		}
+	}
+}
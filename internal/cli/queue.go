@@ -14,20 +14,22 @@ import (
 )
 
 type QueueItem struct {
-	Repo        string   `json:"repo"`
-	Number      int      `json:"number"`
-	Title       string   `json:"title"`
-	URL         string   `json:"url"`
-	Author      string   `json:"author"`
-	CreatedAt   string   `json:"created_at"`
-	UpdatedAt   string   `json:"updated_at"`
-	IsDraft     bool     `json:"is_draft"`
-	Labels      []string `json:"labels"`
-	AgeDays     int      `json:"age_days"`
-	UpdatedDays int      `json:"updated_days"`
-	Checks      string   `json:"checks"`
-	HeadSHA     string   `json:"head_sha"`
-	Size        int      `json:"size"`
+	Repo         string   `json:"repo"`
+	Number       int      `json:"number"`
+	Title        string   `json:"title"`
+	URL          string   `json:"url"`
+	Author       string   `json:"author"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+	IsDraft      bool     `json:"is_draft"`
+	Labels       []string `json:"labels"`
+	AgeDays      int      `json:"age_days"`
+	UpdatedDays  int      `json:"updated_days"`
+	Checks       string   `json:"checks"`
+	HeadSHA      string   `json:"head_sha"`
+	Size         int      `json:"size"`
+	Delta        string   `json:"delta,omitempty"`
+	ReviewStatus string   `json:"review_status"`
 }
 
 func NewQueueCmd() *cobra.Command {
@@ -36,11 +38,13 @@ func NewQueueCmd() *cobra.Command {
 	var owner string
 	var label string
 	var checks string
+	var reviewStatus string
 	var draft string
 	var sortBy string
 	var jsonOut bool
 	var tui bool
 	var mine bool
+	var changed bool
 
 	cmd := &cobra.Command{
 		Use:   "queue",
@@ -50,55 +54,28 @@ func NewQueueCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			opts := pickOptions{limit: limit, repo: repo, owner: owner, label: label, checks: checks, reviewStatus: reviewStatus, draft: draft, sortBy: sortBy, mine: mine}
 			if tui {
 				if jsonOut {
 					return fmt.Errorf("--json is not supported with --tui")
 				}
-				return runPicker(cmd, app, pickOptions{limit: limit, repo: repo, owner: owner, label: label, checks: checks, draft: draft, sortBy: sortBy, mine: mine})
-			}
-
-			if limit == 0 {
-				limit = app.Config.Queue.DefaultLimit
-			}
-			if sortBy == "" {
-				sortBy = app.Config.Queue.DefaultSort
+				if changed {
+					return fmt.Errorf("--changed is not supported with --tui")
+				}
+				return runPicker(cmd, app, opts)
 			}
 
-			query := buildQueueQuery(repo, owner, label, draft)
-			ghSort, order := mapSort(sortBy)
-			mode := github.SearchModeReviewRequested
-			if mine {
-				mode = github.SearchModeMine
-			}
-			items, err := app.GH.SearchPRs(context.Background(), query, limit, ghSort, order, mode)
+			res, err := loadQueue(cmd.Context(), app, opts)
 			if err != nil {
 				return err
 			}
-			queue := buildQueueItems(items)
-
-			if checks != "any" || sortBy == "ci" {
-				queue, err = applyChecks(context.Background(), app.GH, queue, checks)
-				if err != nil {
-					return err
-				}
-			}
-
-			if sortBy == "size" {
-				queue, err = applySizes(context.Background(), app.GH, queue)
-				if err != nil {
-					return err
-				}
-			}
-
-			if checks != "any" {
-				queue = filterByChecks(queue, checks)
+			if changed {
+				res.Items = filterChanged(res.Items)
 			}
-
-			sortQueue(queue, sortBy)
 			if jsonOut {
-				return printQueueJSON(cmd, queue, limit)
+				return printQueueJSON(cmd, res, changed)
 			}
-			return printQueueText(cmd, queue, limit)
+			return printQueueText(cmd, res, changed)
 		},
 	}
 
@@ -107,14 +84,76 @@ func NewQueueCmd() *cobra.Command {
 	cmd.Flags().StringVar(&owner, "owner", "", "Filter by org/owner")
 	cmd.Flags().StringVar(&label, "label", "", "Filter by label")
 	cmd.Flags().StringVar(&checks, "checks", "any", "Filter by checks: failure|pending|success|any")
+	cmd.Flags().StringVar(&reviewStatus, "review-status", "any", "Filter by review status: never-reviewed|reviewed-up-to-date|new-commits-since-review|draft-pending-submit|any")
 	cmd.Flags().StringVar(&draft, "draft", "any", "Filter by draft: true|false|any")
-	cmd.Flags().StringVar(&sortBy, "sort", "", "Sort: oldest|updated|ci|size")
+	cmd.Flags().StringVar(&sortBy, "sort", "", "Sort: oldest|updated|ci|size|status")
 	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output JSON")
 	cmd.Flags().BoolVar(&tui, "tui", false, "Open TUI picker")
 	cmd.Flags().BoolVar(&mine, "mine", false, "Show my authored PRs instead of review requests")
+	cmd.Flags().BoolVar(&changed, "changed", false, "Only show PRs that are new, updated, re-requested, or gone since the last fetch")
 	return cmd
 }
 
+// loadQueue runs the search, enriches items with head SHA and check state,
+// records a snapshot, and annotates each item with its delta against the
+// previous snapshot for the same search scope.
+func loadQueue(ctx context.Context, app *App, opts pickOptions) (queueResult, error) {
+	if err := validateReviewStatus(opts.reviewStatus); err != nil {
+		return queueResult{}, err
+	}
+	if opts.limit == 0 {
+		opts.limit = app.Config.Queue.DefaultLimit
+	}
+	if opts.sortBy == "" {
+		opts.sortBy = app.Config.Queue.DefaultSort
+	}
+	if opts.sortBy == "" {
+		opts.sortBy = "oldest"
+	}
+
+	query := buildQueueQuery(opts.repo, opts.owner, opts.label, opts.draft)
+	ghSort, order := mapSort(opts.sortBy)
+	mode := github.SearchModeReviewRequested
+	if opts.mine {
+		mode = github.SearchModeMine
+	}
+	items, err := app.GH.SearchPRs(ctx, query, opts.limit, ghSort, order, mode)
+	if err != nil {
+		return queueResult{}, err
+	}
+	queue := buildQueueItems(items)
+
+	// Snapshots record head SHA and check state for every PR, so they are
+	// always fetched; the --checks filter is applied after recording.
+	queue, err = applyHeads(ctx, app.GH, queue)
+	if err != nil {
+		return queueResult{}, err
+	}
+	if opts.sortBy == "size" {
+		queue, err = applySizes(ctx, app.GH, queue)
+		if err != nil {
+			return queueResult{}, err
+		}
+	}
+
+	// A full page may have pushed older PRs past the limit, so they cannot
+	// be reported as gone.
+	truncated := len(items) >= opts.limit
+	res, err := recordQueueSnapshot(app.Store, queueScope(mode, query), mode, queue, truncated)
+	if err != nil {
+		return queueResult{}, err
+	}
+	res.Limit = opts.limit
+	if opts.checks != "any" {
+		res.Items = filterByChecks(res.Items, opts.checks)
+	}
+	if opts.reviewStatus != "" && opts.reviewStatus != "any" {
+		res.Items = filterByReviewStatus(res.Items, opts.reviewStatus)
+	}
+	sortQueue(res.Items, opts.sortBy)
+	return res, nil
+}
+
 func buildQueueQuery(repo, owner, label, draft string) string {
 	query := []string{}
 	if repo != "" {
@@ -193,51 +232,31 @@ func parseTime(value string) time.Time {
 	return parsed
 }
 
-func applyChecks(ctx context.Context, gh *github.Client, queue []QueueItem, filter string) ([]QueueItem, error) {
-	for i, item := range queue {
-		if item.Repo == "" || item.Number == 0 {
-			queue[i].Checks = "unknown"
-			continue
-		}
-		headSHA := item.HeadSHA
-		if headSHA == "" {
-			ref := fmt.Sprintf("%s#%d", item.Repo, item.Number)
-			view, err := gh.PRView(ctx, ref)
-			if err != nil {
-				return nil, err
-			}
-			headSHA = view.HeadRefOid
-			queue[i].HeadSHA = headSHA
+// applyHeads sets each item's head SHA and check state, looking all of
+// them up in a few batched requests.
+func applyHeads(ctx context.Context, gh *github.Client, queue []QueueItem) ([]QueueItem, error) {
+	refs := make([]string, 0, len(queue))
+	for _, item := range queue {
+		if item.Repo != "" && item.Number != 0 {
+			refs = append(refs, fmt.Sprintf("%s#%d", item.Repo, item.Number))
 		}
-		if headSHA == "" {
+	}
+	heads, err := gh.PRHeads(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range queue {
+		head, ok := heads[fmt.Sprintf("%s#%d", item.Repo, item.Number)]
+		if !ok {
 			queue[i].Checks = "unknown"
 			continue
 		}
-		resp, err := gh.CheckRuns(ctx, item.Repo, headSHA)
-		if err != nil {
-			return nil, err
-		}
-		queue[i].Checks = summarizeChecks(resp)
+		queue[i].HeadSHA = head.SHA
+		queue[i].Checks = head.Checks
 	}
 	return queue, nil
 }
 
-func summarizeChecks(resp github.CheckRunsResponse) string {
-	if resp.Total == 0 {
-		return "none"
-	}
-	status := "success"
-	for _, run := range resp.Runs {
-		if run.Conclusion == "failure" || run.Conclusion == "cancelled" || run.Conclusion == "timed_out" {
-			return "failure"
-		}
-		if run.Status != "completed" {
-			status = "pending"
-		}
-	}
-	return status
-}
-
 func filterByChecks(queue []QueueItem, filter string) []QueueItem {
 	if filter == "any" {
 		return queue
@@ -265,6 +284,14 @@ func sortQueue(queue []QueueItem, sortBy string) {
 		sort.Slice(queue, func(i, j int) bool {
 			return queue[i].Size > queue[j].Size
 		})
+	case "status":
+		sort.SliceStable(queue, func(i, j int) bool {
+			ri, rj := reviewStatusRank(queue[i].ReviewStatus), reviewStatusRank(queue[j].ReviewStatus)
+			if ri != rj {
+				return ri < rj
+			}
+			return queue[i].CreatedAt < queue[j].CreatedAt
+		})
 	default:
 		sort.Slice(queue, func(i, j int) bool {
 			return queue[i].CreatedAt < queue[j].CreatedAt
@@ -298,29 +325,50 @@ func sumSize(files []github.PRFile) int {
 	return size
 }
 
-func printQueueText(cmd *cobra.Command, queue []QueueItem, limit int) error {
-	if len(queue) == 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), "No PRs found.")
+func printQueueText(cmd *cobra.Command, res queueResult, changed bool) error {
+	queue := res.Items
+	limit := res.Limit
+	if changed && res.Baseline {
+		fmt.Fprintln(cmd.OutOrStdout(), "No previous queue snapshot to compare against; recorded a baseline.")
+		return nil
+	}
+	if len(queue) == 0 && len(res.Gone) == 0 {
+		if changed {
+			fmt.Fprintln(cmd.OutOrStdout(), "No changes since the last queue fetch.")
+		} else {
+			fmt.Fprintln(cmd.OutOrStdout(), "No PRs found.")
+		}
 		return nil
 	}
 	for _, item := range queue {
-		fmt.Fprintf(cmd.OutOrStdout(), "%s#%d %s\n", item.Repo, item.Number, item.Title)
-		fmt.Fprintf(cmd.OutOrStdout(), "  Author: %s  Age: %dd  Updated: %dd  Draft: %v  Checks: %s\n", item.Author, item.AgeDays, item.UpdatedDays, item.IsDraft, item.Checks)
+		fmt.Fprintf(cmd.OutOrStdout(), "%s%s#%d %s\n", deltaPrefix(item.Delta), item.Repo, item.Number, item.Title)
+		fmt.Fprintf(cmd.OutOrStdout(), "  Author: %s  Age: %dd  Updated: %dd  Draft: %v  Checks: %s  Review: %s\n", item.Author, item.AgeDays, item.UpdatedDays, item.IsDraft, item.Checks, item.ReviewStatus)
 		fmt.Fprintf(cmd.OutOrStdout(), "  URL: %s\n", item.URL)
 	}
-	if len(queue) >= limit {
+	for _, item := range res.Gone {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s%s#%d %s\n", deltaPrefix(item.Delta), item.Repo, item.Number, item.Title)
+	}
+	if !changed && len(queue) >= limit {
 		fmt.Fprintf(cmd.OutOrStdout(), "Showing first %d results. Refine with filters or raise --limit.\n", limit)
 	}
 	return nil
 }
 
-func printQueueJSON(cmd *cobra.Command, queue []QueueItem, limit int) error {
+func printQueueJSON(cmd *cobra.Command, res queueResult, changed bool) error {
 	payload := map[string]any{
-		"items": queue,
-		"limit": limit,
+		"items":    res.Items,
+		"limit":    res.Limit,
+		"baseline": res.Baseline,
+	}
+	if len(res.Gone) > 0 {
+		payload["gone"] = res.Gone
 	}
-	if len(queue) == 0 {
-		payload["message"] = "No PRs found."
+	if len(res.Items) == 0 && len(res.Gone) == 0 {
+		if changed {
+			payload["message"] = "No changes since the last queue fetch."
+		} else {
+			payload["message"] = "No PRs found."
+		}
 	}
 	data, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/store"
+)
+
+const (
+	queueDeltaNew         = "NEW"
+	queueDeltaUpdated     = "UPDATED"
+	queueDeltaReRequested = "RE-REQUESTED"
+	queueDeltaGone        = "GONE"
+)
+
+type queueResult struct {
+	Items []QueueItem
+	// Gone lists PRs from the previous snapshot that no longer appear.
+	Gone  []QueueItem
+	Limit int
+	// Baseline is true when no earlier snapshot existed for this scope, so
+	// no deltas could be computed.
+	Baseline bool
+}
+
+// queueScope identifies a queue search so that deltas are only computed
+// against a snapshot taken with the same mode and filters.
+func queueScope(mode github.SearchMode, query string) string {
+	return fmt.Sprintf("%s|%s", mode, query)
+}
+
+// recordQueueSnapshot records queue for scope and annotates it against the
+// previous snapshot. When the search was truncated at its limit, PRs
+// missing from it are not reported as gone.
+func recordQueueSnapshot(st *store.Store, scope string, mode github.SearchMode, queue []QueueItem, truncated bool) (queueResult, error) {
+	res := queueResult{Items: queue}
+	err := st.InTx(func(tx *store.Store) error {
+		prev, err := tx.LatestQueueSnapshot(scope)
+		if err == sql.ErrNoRows {
+			res.Baseline = true
+		} else if err != nil {
+			return err
+		}
+
+		states := map[string]store.PRState{}
+		items := make([]store.QueueSnapshotItem, 0, len(queue))
+		for i, item := range queue {
+			ref := fmt.Sprintf("%s#%d", item.Repo, item.Number)
+			if item.HeadSHA != "" {
+				if err := tx.UpsertPR(ref, item.Repo, item.Number, item.HeadSHA); err != nil {
+					return err
+				}
+			}
+			state, err := tx.GetPR(ref)
+			known := err == nil
+			if known {
+				states[ref] = state
+			} else if err != sql.ErrNoRows {
+				return err
+			}
+			hasDraft := false
+			if known {
+				if _, err := tx.GetDraftReview(ref); err == nil {
+					hasDraft = true
+				} else if err != sql.ErrNoRows {
+					return err
+				}
+			}
+			res.Items[i].ReviewStatus = deriveReviewStatus(state, known, hasDraft, item.HeadSHA)
+			items = append(items, store.QueueSnapshotItem{
+				PRID:    ref,
+				Repo:    item.Repo,
+				Number:  item.Number,
+				Title:   item.Title,
+				HeadSHA: item.HeadSHA,
+				Checks:  item.Checks,
+			})
+		}
+
+		if !res.Baseline {
+			res.Gone = annotateQueue(res.Items, prev, states, mode)
+			if truncated {
+				res.Gone = nil
+			}
+		}
+		_, err = tx.RecordQueueSnapshot(scope, items)
+		return err
+	})
+	if err != nil {
+		return queueResult{}, err
+	}
+	return res, nil
+}
+
+// annotateQueue sets Delta on each queue item relative to the previous
+// snapshot and returns the previously seen PRs that have left the queue.
+func annotateQueue(queue []QueueItem, prev store.QueueSnapshot, states map[string]store.PRState, mode github.SearchMode) []QueueItem {
+	seen := map[string]bool{}
+	prevHeads := map[string]string{}
+	for _, item := range prev.Items {
+		seen[item.PRID] = true
+		prevHeads[item.PRID] = item.HeadSHA
+	}
+	current := map[string]bool{}
+	for i, item := range queue {
+		ref := fmt.Sprintf("%s#%d", item.Repo, item.Number)
+		current[ref] = true
+		state, known := states[ref]
+		switch {
+		case !seen[ref] && mode == github.SearchModeReviewRequested && known && state.LastSubmittedAt.Valid:
+			// Submitting a review clears the request, so a PR we already
+			// reviewed showing up again means the author asked again.
+			queue[i].Delta = queueDeltaReRequested
+		case !seen[ref]:
+			queue[i].Delta = queueDeltaNew
+		case item.HeadSHA != "" && prevHeads[ref] != "" && prevHeads[ref] != item.HeadSHA:
+			queue[i].Delta = queueDeltaUpdated
+		}
+	}
+
+	gone := []QueueItem{}
+	for _, item := range prev.Items {
+		if current[item.PRID] {
+			continue
+		}
+		gone = append(gone, QueueItem{
+			Repo:    item.Repo,
+			Number:  item.Number,
+			Title:   item.Title,
+			HeadSHA: item.HeadSHA,
+			Checks:  item.Checks,
+			Delta:   queueDeltaGone,
+		})
+	}
+	return gone
+}
+
+func filterChanged(queue []QueueItem) []QueueItem {
+	filtered := []QueueItem{}
+	for _, item := range queue {
+		if item.Delta != "" {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+func deltaPrefix(delta string) string {
+	if delta == "" {
+		return ""
+	}
+	return "[" + delta + "] "
+}
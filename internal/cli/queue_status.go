@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/brianndofor/prq/internal/store"
+)
+
+const (
+	reviewStatusNeverReviewed = "never-reviewed"
+	reviewStatusUpToDate      = "reviewed-up-to-date"
+	reviewStatusNewCommits    = "new-commits-since-review"
+	reviewStatusDraftPending  = "draft-pending-submit"
+)
+
+// deriveReviewStatus classifies a PR using the head we last reviewed and
+// whether a local draft is waiting to be submitted. New commits take
+// precedence over a pending draft because the draft is then stale.
+func deriveReviewStatus(state store.PRState, known bool, hasDraft bool, headSHA string) string {
+	if !known || !state.LastReviewedHeadSHA.Valid || state.LastReviewedHeadSHA.String == "" {
+		return reviewStatusNeverReviewed
+	}
+	if headSHA != "" && state.LastReviewedHeadSHA.String != headSHA {
+		return reviewStatusNewCommits
+	}
+	if hasDraft {
+		return reviewStatusDraftPending
+	}
+	return reviewStatusUpToDate
+}
+
+// reviewStatusRank orders statuses by how much attention they need.
+func reviewStatusRank(status string) int {
+	switch status {
+	case reviewStatusNewCommits:
+		return 0
+	case reviewStatusDraftPending:
+		return 1
+	case reviewStatusNeverReviewed:
+		return 2
+	case reviewStatusUpToDate:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func filterByReviewStatus(queue []QueueItem, status string) []QueueItem {
+	filtered := []QueueItem{}
+	for _, item := range queue {
+		if item.ReviewStatus == status {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// validateReviewStatus rejects a --review-status value that no PR can have,
+// which would otherwise filter out the whole queue.
+func validateReviewStatus(status string) error {
+	switch status {
+	case "", "any", reviewStatusNeverReviewed, reviewStatusUpToDate, reviewStatusNewCommits, reviewStatusDraftPending:
+		return nil
+	}
+	return fmt.Errorf("invalid --review-status %q: expected %s, %s, %s, %s, or any", status, reviewStatusNeverReviewed, reviewStatusUpToDate, reviewStatusNewCommits, reviewStatusDraftPending)
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/store"
+)
+
+func TestAnnotateQueue(t *testing.T) {
+	prev := store.QueueSnapshot{Items: []store.QueueSnapshotItem{
+		{PRID: "acme/app#1", Repo: "acme/app", Number: 1, Title: "Unchanged", HeadSHA: "a1"},
+		{PRID: "acme/app#2", Repo: "acme/app", Number: 2, Title: "Pushed", HeadSHA: "b1"},
+		{PRID: "acme/app#3", Repo: "acme/app", Number: 3, Title: "Merged", HeadSHA: "c1"},
+	}}
+	queue := []QueueItem{
+		{Repo: "acme/app", Number: 1, HeadSHA: "a1"},
+		{Repo: "acme/app", Number: 2, HeadSHA: "b2"},
+		{Repo: "acme/app", Number: 4, HeadSHA: "d1"},
+		{Repo: "acme/app", Number: 5, HeadSHA: "e2"},
+	}
+	states := map[string]store.PRState{
+		// Reviewed at an older head, but unchanged since the last fetch.
+		"acme/app#1": {LastReviewedHeadSHA: sql.NullString{String: "a0", Valid: true}},
+		"acme/app#5": {
+			LastReviewedHeadSHA: sql.NullString{String: "e1", Valid: true},
+			LastSubmittedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+		},
+	}
+
+	gone := annotateQueue(queue, prev, states, github.SearchModeReviewRequested)
+
+	want := []string{"", queueDeltaUpdated, queueDeltaNew, queueDeltaReRequested}
+	for i, item := range queue {
+		if item.Delta != want[i] {
+			t.Fatalf("item %d: expected delta %q, got %q", item.Number, want[i], item.Delta)
+		}
+	}
+	if len(gone) != 1 || gone[0].Number != 3 || gone[0].Delta != queueDeltaGone {
+		t.Fatalf("unexpected gone items: %#v", gone)
+	}
+	if changed := filterChanged(queue); len(changed) != 3 {
+		t.Fatalf("expected 3 changed items, got %d", len(changed))
+	}
+}
+
+func TestRecordQueueSnapshotSkipsGoneWhenTruncated(t *testing.T) {
+	st, err := store.Open(filepath.Join(t.TempDir(), "prq.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	both := []QueueItem{{Repo: "acme/app", Number: 1, HeadSHA: "a1"}, {Repo: "acme/app", Number: 2, HeadSHA: "b1"}}
+	if _, err := recordQueueSnapshot(st, "review|", github.SearchModeReviewRequested, both, false); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	res, err := recordQueueSnapshot(st, "review|", github.SearchModeReviewRequested, []QueueItem{{Repo: "acme/app", Number: 1, HeadSHA: "a1"}}, true)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if len(res.Gone) != 0 {
+		t.Fatalf("expected no gone items from a truncated search, got %#v", res.Gone)
+	}
+	res, err = recordQueueSnapshot(st, "review|", github.SearchModeReviewRequested, []QueueItem{{Repo: "acme/app", Number: 2, HeadSHA: "b1"}}, false)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if len(res.Gone) != 1 || res.Gone[0].Number != 1 {
+		t.Fatalf("expected #1 to be gone, got %#v", res.Gone)
+	}
+}
+
+func TestQueueChangedCommand(t *testing.T) {
+	cleanup := withMockEnv(t)
+	defer cleanup()
+
+	output := runRoot(t, "queue", "--changed")
+	if !strings.Contains(output, "recorded a baseline") {
+		t.Fatalf("expected first fetch to record a baseline, got: %q", output)
+	}
+	output = runRoot(t, "queue", "--changed")
+	if !strings.Contains(output, "No changes since the last queue fetch.") {
+		t.Fatalf("expected no changes on second fetch, got: %q", output)
+	}
+}
+
+func TestDeriveReviewStatusAndSort(t *testing.T) {
+	reviewed := store.PRState{LastReviewedHeadSHA: sql.NullString{String: "h1", Valid: true}}
+	cases := []struct {
+		name     string
+		state    store.PRState
+		known    bool
+		hasDraft bool
+		head     string
+		want     string
+	}{
+		{"unknown pr", store.PRState{}, false, false, "h1", reviewStatusNeverReviewed},
+		{"seen but not reviewed", store.PRState{}, true, false, "h1", reviewStatusNeverReviewed},
+		{"same head", reviewed, true, false, "h1", reviewStatusUpToDate},
+		{"same head with draft", reviewed, true, true, "h1", reviewStatusDraftPending},
+		{"pushed after review", reviewed, true, true, "h2", reviewStatusNewCommits},
+	}
+	for _, tc := range cases {
+		if got := deriveReviewStatus(tc.state, tc.known, tc.hasDraft, tc.head); got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+
+	queue := []QueueItem{
+		{Number: 1, CreatedAt: "2026-01-01T00:00:00Z", ReviewStatus: reviewStatusUpToDate},
+		{Number: 2, CreatedAt: "2026-01-02T00:00:00Z", ReviewStatus: reviewStatusNeverReviewed},
+		{Number: 3, CreatedAt: "2026-01-03T00:00:00Z", ReviewStatus: reviewStatusNewCommits},
+		{Number: 4, CreatedAt: "2026-01-04T00:00:00Z", ReviewStatus: reviewStatusDraftPending},
+	}
+	sortQueue(queue, "status")
+	order := []int{queue[0].Number, queue[1].Number, queue[2].Number, queue[3].Number}
+	if order[0] != 3 || order[1] != 4 || order[2] != 2 || order[3] != 1 {
+		t.Fatalf("unexpected status order: %v", order)
+	}
+	if err := validateReviewStatus("new-commits"); err == nil {
+		t.Fatalf("expected an unknown review status to be rejected")
+	}
+	if err := validateReviewStatus(reviewStatusDraftPending); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if got := filterByReviewStatus(queue, reviewStatusNewCommits); len(got) != 1 || got[0].Number != 3 {
+		t.Fatalf("unexpected filter result: %#v", got)
+	}
+}
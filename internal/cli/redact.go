@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/brianndofor/prq/internal/redact"
+	"github.com/spf13/cobra"
+)
+
+func NewRedactCmd() *cobra.Command {
+	var dryRun bool
+	var runTests bool
+	var sinceLast bool
+	var expandCtx bool
+	var profileName string
+
+	cmd := &cobra.Command{
+		Use:   "redact --dry-run <pr-url|OWNER/REPO#123>",
+		Short: "Show the review prompt with its redactions highlighted, without running the provider",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !dryRun {
+				return fmt.Errorf("prq redact only supports --dry-run")
+			}
+			app, err := getApp(cmd.Context())
+			if err != nil {
+				return err
+			}
+			in, err := prepareReview(cmd.Context(), app, args[0], reviewOptions{runTests: runTests, sinceLast: sinceLast, context: expandCtx, profile: profileName, warn: cmd.ErrOrStderr()})
+			if err != nil {
+				return err
+			}
+			if line := profileLine(in.profile); line != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n", line)
+			}
+			writeRedactionReport(cmd.OutOrStdout(), in.prompt, in.audit.Findings, app.Config.Redaction.Enabled)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Render the prompt and list redactions; nothing is sent")
+	cmd.Flags().BoolVar(&runTests, "run-tests", false, "Include test results, analyzer findings, and related code as `prq review --run-tests` would")
+	cmd.Flags().BoolVar(&sinceLast, "since-last", false, "Render the incremental prompt used by --since-last")
+	cmd.Flags().BoolVar(&expandCtx, "context", false, "Include surrounding file contents for each hunk (overrides context.enabled)")
+	cmd.Flags().StringVar(&profileName, "profile", "", "Review profile to apply (default: chosen by auto_profiles; \"none\" disables them)")
+	return cmd
+}
+
+// writeRedactionReport prints the exact prompt with each placeholder
+// highlighted, then one row per redaction.
+func writeRedactionReport(out io.Writer, promptText string, findings []redact.Finding, enabled bool) {
+	fmt.Fprintf(out, "%s%s══ Prompt ══%s\n\n", colorBold, colorBlue, colorReset)
+	highlighted := promptText
+	for _, p := range redact.Placeholders(promptText) {
+		highlighted = strings.ReplaceAll(highlighted, p, colorBold+colorRed+p+colorReset)
+	}
+	fmt.Fprintln(out, highlighted)
+
+	fmt.Fprintf(out, "\n%s%s══ Redactions ══%s\n\n", colorBold, colorBlue, colorReset)
+	if !enabled {
+		fmt.Fprintln(out, "  Redaction is disabled (redaction.enabled: false); the prompt is sent as shown.")
+		return
+	}
+	if len(findings) == 0 {
+		fmt.Fprintf(out, "  %sNo redactions%s\n", colorGreen, colorReset)
+		return
+	}
+	fmt.Fprintf(out, "  %s\n\n", redact.Summary(findings))
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  PLACEHOLDER\tRULE\tLOCATION\tLENGTH\tHASH")
+	for _, f := range findings {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%d\t%s\n", f.Placeholder, f.Rule, f.Location(), f.Length, f.Hash)
+	}
+	_ = tw.Flush()
+}
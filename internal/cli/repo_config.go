@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/config"
+	"github.com/brianndofor/prq/internal/github"
+)
+
+// remoteConfigPaths are checked in order on the PR's base branch.
+var remoteConfigPaths = []string{"prq.yaml", ".github/prq.yaml"}
+
+// useRepoConfig replaces the repo config with the one committed to repo at
+// sha, usually the PR's base, with ./prq.yaml merged over it as a local
+// override. Reviewing from outside a checkout then still follows the
+// repo's settings, and a PR cannot change its own review settings. Only the
+// committed config's safe keys are applied; test commands, analyzers,
+// sandbox and environment settings, redaction allowlists, auto_profiles,
+// and profile prompts and schemas come from ./prq.yaml or the user config.
+// The keys that were ignored are listed on warn, unless it is nil.
+func useRepoConfig(ctx context.Context, app *App, repo, sha string, warn io.Writer) error {
+	var layers []config.RepoSource
+	remote, err := fetchRepoConfig(ctx, app, repo, sha)
+	if err != nil {
+		return err
+	}
+	if remote != nil {
+		layers = append(layers, *remote)
+		ignored, err := remote.IgnoredKeys()
+		if err != nil {
+			return err
+		}
+		if warn != nil && len(ignored) > 0 {
+			fmt.Fprintf(warn, "Warning: ignoring %s from %s; set them in ./prq.yaml to use them.\n", strings.Join(ignored, ", "), remote.Origin)
+		}
+	}
+	local, err := config.LocalRepoSource()
+	if err != nil {
+		return err
+	}
+	if local != nil {
+		layers = append(layers, *local)
+	}
+	repoCfg, err := config.LoadRepo(app.Config, layers)
+	if err != nil {
+		return err
+	}
+	redactor, err := newRedactor(app.Config, repoCfg)
+	if err != nil {
+		return err
+	}
+	app.RepoConfig = repoCfg
+	app.Redactor = redactor
+	app.RepoSources = layers
+	return nil
+}
+
+// fetchRepoConfig returns the first of remoteConfigPaths in repo at sha, or
+// nil if there is none. Results, including misses, are cached per commit.
+func fetchRepoConfig(ctx context.Context, app *App, repo, sha string) (*config.RepoSource, error) {
+	cached, err := app.Store.GetRepoConfig(repo, sha)
+	if err == nil {
+		return repoSource(repo, sha, cached.Path, cached.Content), nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+	var path, content string
+	for _, candidate := range remoteConfigPaths {
+		content, err = app.GH.FileContents(ctx, repo, candidate, sha)
+		if err == nil {
+			path = candidate
+			break
+		}
+		if !errors.Is(err, github.ErrNotFound) {
+			return nil, fmt.Errorf("failed to fetch repo config: %w", err)
+		}
+		content = ""
+	}
+	if err := app.Store.PutRepoConfig(repo, sha, path, content); err != nil {
+		return nil, err
+	}
+	return repoSource(repo, sha, path, content), nil
+}
+
+func repoSource(repo, sha, path, content string) *config.RepoSource {
+	if path == "" {
+		return nil
+	}
+	return &config.RepoSource{Origin: fmt.Sprintf("%s@%s:%s", repo, sha, path), Content: []byte(content), Remote: true}
+}
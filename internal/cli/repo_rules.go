@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/config"
+	"github.com/brianndofor/prq/internal/diff"
+	"github.com/brianndofor/prq/internal/github"
+)
+
+// guidelineCandidates are checked in order when no guidelines are
+// configured, following where GitHub looks for CODEOWNERS.
+var guidelineCandidates = []string{
+	".github/REVIEW_GUIDELINES.md",
+	"REVIEW_GUIDELINES.md",
+	"docs/REVIEW_GUIDELINES.md",
+}
+
+// guidelineReader reads a guideline file by its slash-separated repo path,
+// returning an error wrapping fs.ErrNotExist if there is none.
+type guidelineReader func(path string) ([]byte, error)
+
+// baseGuidelines reads guideline files from repo at the PR's base commit,
+// where the repo config may also have come from, never from the working
+// directory or outside the repo.
+func baseGuidelines(ctx context.Context, app *App, repo, sha string) guidelineReader {
+	return func(path string) ([]byte, error) {
+		clean, err := config.RepoPath(path)
+		if err != nil {
+			return nil, err
+		}
+		text, err := app.GH.FileContents(ctx, repo, clean, sha)
+		if errors.Is(err, github.ErrNotFound) {
+			return nil, fmt.Errorf("%s: %w", clean, fs.ErrNotExist)
+		}
+		return []byte(text), err
+	}
+}
+
+// repoRules returns the repo_rules and guideline rules that apply to a PR
+// changing paths. A rule scoped by paths says which paths it applies to.
+func repoRules(repoCfg config.RepoConfig, read guidelineReader, paths []string) ([]string, error) {
+	var rules []string
+	add := func(rule string, scope []string) {
+		if len(scope) == 0 {
+			rules = append(rules, rule)
+			return
+		}
+		if _, _, ok := diff.MatchAnyGlob(scope, paths); ok {
+			rules = append(rules, fmt.Sprintf("%s (applies to %s)", rule, strings.Join(scope, ", ")))
+		}
+	}
+	for _, rule := range repoCfg.RepoRules {
+		add(strings.TrimSpace(rule.Rule), rule.Paths)
+	}
+
+	for _, file := range repoCfg.Guidelines {
+		content, err := read(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read review guidelines: %w", err)
+		}
+		for _, rule := range parseGuidelines(string(content)) {
+			add(rule, file.Paths)
+		}
+	}
+	if len(repoCfg.Guidelines) > 0 {
+		return rules, nil
+	}
+	for _, candidate := range guidelineCandidates {
+		content, err := read(candidate)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read review guidelines: %w", err)
+		}
+		for _, rule := range parseGuidelines(string(content)) {
+			add(rule, nil)
+		}
+		break
+	}
+	return rules, nil
+}
+
+var listItemRE = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.*)$`)
+
+// parseGuidelines returns the top-level list items of a Markdown document
+// as rules, joining wrapped lines and nested items into their parent item.
+// Headings, prose, and code blocks are skipped. A document without a list
+// is a single rule.
+func parseGuidelines(text string) []string {
+	var rules []string
+	inFence := false
+	inItem := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			inItem = false
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		m := listItemRE.FindStringSubmatch(line)
+		switch {
+		case m != nil && !indented:
+			rules = append(rules, strings.TrimSpace(m[1]))
+			inItem = true
+		case inItem && indented:
+			if m != nil {
+				trimmed = strings.TrimSpace(m[1])
+			}
+			rules[len(rules)-1] += " " + trimmed
+		default:
+			inItem = false
+		}
+	}
+	if len(rules) == 0 {
+		if text = strings.TrimSpace(text); text != "" {
+			return []string{text}
+		}
+	}
+	return rules
+}
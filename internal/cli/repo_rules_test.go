@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoRulesScopedByPath(t *testing.T) {
+	cleanup := withMockEnv(t)
+	defer cleanup()
+
+	// Guidelines are served from the base branch; a copy in the working
+	// directory is ignored.
+	fixtures := t.TempDir()
+	if err := os.CopyFS(fixtures, os.DirFS(os.Getenv("PRQ_MOCK_DIR"))); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PRQ_MOCK_DIR", fixtures)
+	repoDir := t.TempDir()
+	files := map[string]string{
+		filepath.Join(repoDir, "prq.yaml"): `repo_rules:
+  - "Follow repo style guidelines"
+  - rule: "Migrations must be reversible"
+    paths: ["migrations/**"]
+  - rule: "Auth changes need a security test"
+    paths: ["internal/auth/**"]
+`,
+		filepath.Join(fixtures, "contents", ".github", "REVIEW_GUIDELINES.md"): "# Review guidelines\n\nIntro prose is skipped.\n\n" +
+			"- Wrap errors with context,\n  naming the failed operation.\n" +
+			"- Log at the boundary only.\n  - Never log tokens.\n\n" +
+			"```go\n- not a rule\n```\n",
+		filepath.Join(repoDir, "REVIEW_GUIDELINES.md"): "- Local rule from the working directory\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(repoDir)
+
+	output := runRoot(t, "redact", "--dry-run", "acme/app#42")
+	want := "Repo rules\n" +
+		"- Follow repo style guidelines\n" +
+		"- Auth changes need a security test (applies to internal/auth/**)\n" +
+		"- Wrap errors with context, naming the failed operation.\n" +
+		"- Log at the boundary only. Never log tokens.\n"
+	if !strings.Contains(output, want) {
+		t.Fatalf("expected scoped repo rules and guidelines, got:\n%s", output)
+	}
+	if strings.Contains(output, "Migrations must be reversible") || strings.Contains(output, "not a rule") {
+		t.Fatalf("expected out-of-scope rules and code blocks to be skipped, got:\n%s", output)
+	}
+	if strings.Contains(output, "Local rule from the working directory") {
+		t.Fatalf("expected guidelines to come only from the base branch, got:\n%s", output)
+	}
+
+	for path, want := range map[string]string{"/etc/passwd": "must be relative to the repo root", "../other/GUIDE.md": "must not contain .."} {
+		if err := os.WriteFile(filepath.Join(repoDir, "prq.yaml"), []byte("guidelines:\n  - "+path+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cmd := NewRootCmd()
+		cmd.SetOut(&strings.Builder{})
+		cmd.SetErr(&strings.Builder{})
+		cmd.SetArgs([]string{"redact", "--dry-run", "acme/app#42"})
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected guidelines path %s to be rejected, got %v", path, err)
+		}
+	}
+}
+
+func TestParseGuidelinesWithoutList(t *testing.T) {
+	rules := parseGuidelines("\nKeep handlers thin.\n")
+	if len(rules) != 1 || rules[0] != "Keep handlers thin." {
+		t.Fatalf("unexpected rules: %q", rules)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/brianndofor/prq/internal/github"
 	"github.com/brianndofor/prq/internal/provider"
+	"github.com/brianndofor/prq/internal/redact"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +15,9 @@ func NewReviewCmd() *cobra.Command {
 	var format string
 	var maxIssues int
 	var runTests bool
+	var sinceLast bool
+	var expandCtx bool
+	var profileName string
 
 	cmd := &cobra.Command{
 		Use:   "review <pr-url|OWNER/REPO#123>",
@@ -25,41 +29,56 @@ func NewReviewCmd() *cobra.Command {
 				return err
 			}
 			ctx := cmd.Context()
-			run, err := generateReviewPlan(ctx, app, args[0], maxIssues, runTests)
+			run, err := generateReviewPlan(ctx, app, args[0], reviewOptions{maxIssues: maxIssues, runTests: runTests, sinceLast: sinceLast, context: expandCtx, profile: profileName, warn: cmd.ErrOrStderr()})
 			if err != nil {
 				return err
 			}
 
-			if err := app.Store.UpsertPR(run.FullRef, run.View.Repository.NameWithOwner, run.View.Number, run.View.HeadRefOid); err != nil {
-				return err
-			}
-			if err := app.Store.MarkReviewed(run.FullRef, run.View.HeadRefOid); err != nil {
-				return err
-			}
-			payload := DraftReviewPayload{
-				Repo:    run.View.Repository.NameWithOwner,
-				Number:  run.View.Number,
-				BaseSHA: run.View.BaseRefOid,
-				HeadSHA: run.View.HeadRefOid,
-				Plan:    run.Plan,
-			}
-			payloadJSON, err := json.Marshal(payload)
+			payload, _, err := saveDraft(app, run)
 			if err != nil {
 				return err
 			}
-			if err := app.Store.UpsertDraftReview(run.FullRef, string(payloadJSON), renderDraftPreview(payload)); err != nil {
-				return err
-			}
 			if format != "json" {
-				fmt.Fprintf(cmd.OutOrStdout(), "Draft saved. Run `prq submit %s` to post to GitHub.\n\n", run.FullRef)
+				if payload.Version > 1 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Draft v%d saved. Run `prq submit %s` to post to GitHub.\n\n", payload.Version, run.FullRef)
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "Draft saved. Run `prq submit %s` to post to GitHub.\n\n", run.FullRef)
+				}
+				if line := profileLine(run.Profile); line != "" {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n", line)
+				}
+				if run.OutOfProfile > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Dropped %d issue(s) outside the profile's categories.\n\n", run.OutOfProfile)
+				}
+				if run.Stale > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Dropped %d earlier issue(s) whose lines changed or left the diff.\n\n", run.Stale)
+				}
+				if run.Dropped > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Dropped %d issue(s) already raised in open review threads.\n\n", run.Dropped)
+				}
+				if len(run.Redactions) > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Redactions: %s. Run `prq redact --dry-run %s` to inspect them.\n\n", redact.Summary(run.Redactions), run.FullRef)
+				}
+				if run.Scrubbed > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "WARNING: the model reproduced %d redacted value(s); they were replaced with their placeholders.\n\n", run.Scrubbed)
+				}
+				if run.ToolIssues > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Added %d analyzer finding(s) on changed lines.\n\n", run.ToolIssues)
+				}
 			}
 
 			switch format {
 			case "json":
 				return printReviewJSON(cmd, run.Plan, run.Raw)
 			case "md":
+				if len(run.Tests) > 0 {
+					writeTestTable(cmd, run.Tests, run.TestSelection, true)
+				}
 				return printReviewMarkdown(cmd, run.Plan)
 			default:
+				if len(run.Tests) > 0 {
+					writeTestTable(cmd, run.Tests, run.TestSelection, false)
+				}
 				return printReviewText(cmd, run.Plan)
 			}
 		},
@@ -68,6 +87,9 @@ func NewReviewCmd() *cobra.Command {
 	cmd.Flags().StringVar(&format, "format", "text", "text|json|md")
 	cmd.Flags().IntVar(&maxIssues, "max-issues", 0, "Limit issues count")
 	cmd.Flags().BoolVar(&runTests, "run-tests", false, "Run repo tests before review")
+	cmd.Flags().BoolVar(&sinceLast, "since-last", false, "Review only changes since the last reviewed head and merge into the previous draft")
+	cmd.Flags().BoolVar(&expandCtx, "context", false, "Include surrounding file contents for each hunk (overrides context.enabled)")
+	cmd.Flags().StringVar(&profileName, "profile", "", "Review profile to apply (default: chosen by auto_profiles; \"none\" disables them)")
 	return cmd
 }
 
@@ -231,9 +253,13 @@ func writeReview(cmd *cobra.Command, plan provider.ReviewPlan, markdown bool) {
 			for _, issue := range issuesByFile[file] {
 				sevC := severityColor(issue.Severity)
 				if markdown {
-					line(fmt.Sprintf("- **[%s/%s]** %s (L%d-%d)", issue.Severity, issue.Category, issue.Message, issue.StartLine, issue.EndLine))
+					line(fmt.Sprintf("- **[%s]** %s (L%d-%d)", issueTag(issue), issue.Message, issue.StartLine, issue.EndLine))
+				} else if issue.Tool != "" {
+					line(fmt.Sprintf("     %s[tool]%s %s%s%s", colorBlue, colorReset, colorDim, issue.Tool, colorReset))
 				} else {
 					line(fmt.Sprintf("     %s[%s]%s %s%s%s", sevC, issue.Severity, colorReset, colorDim, issue.Category, colorReset))
+				}
+				if !markdown {
 					line(fmt.Sprintf("     %s", issue.Message))
 					line(fmt.Sprintf("     %sLines %d-%d%s", colorDim, issue.StartLine, issue.EndLine, colorReset))
 				}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/provider"
+)
+
+// renderExistingComments summarizes review threads and top-level reviews
+// other reviewers have already left so the model does not repeat them.
+func renderExistingComments(threads []github.ReviewThread, reviews []github.Review) string {
+	var b strings.Builder
+	for _, thread := range threads {
+		if len(thread.Comments) == 0 {
+			continue
+		}
+		status := "unresolved"
+		if thread.IsResolved {
+			status = "resolved"
+		} else if thread.IsOutdated {
+			status = "outdated"
+		}
+		fmt.Fprintf(&b, "- %s (%s)\n", threadRange(thread), status)
+		for _, comment := range thread.Comments {
+			fmt.Fprintf(&b, "  %s: %s\n", comment.Author, oneLine(comment.Body))
+		}
+	}
+	for _, review := range reviews {
+		if strings.TrimSpace(review.Body) == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "- Review by %s (%s): %s\n", review.User.Login, strings.ToLower(review.State), oneLine(review.Body))
+	}
+	if b.Len() == 0 {
+		return "None"
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func threadRange(thread github.ReviewThread) string {
+	if thread.Line == nil {
+		return thread.Path
+	}
+	if thread.StartLine != nil && *thread.StartLine < *thread.Line {
+		return fmt.Sprintf("%s:%d-%d", thread.Path, *thread.StartLine, *thread.Line)
+	}
+	return fmt.Sprintf("%s:%d", thread.Path, *thread.Line)
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// dropCoveredIssues removes issues that overlap an unresolved review thread on
+// the same file and line range, returning the kept issues and the drop count.
+func dropCoveredIssues(issues []provider.Issue, threads []github.ReviewThread) ([]provider.Issue, int) {
+	kept := make([]provider.Issue, 0, len(issues))
+	dropped := 0
+	for _, issue := range issues {
+		if coveredByThread(issue, threads) {
+			dropped++
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept, dropped
+}
+
+func coveredByThread(issue provider.Issue, threads []github.ReviewThread) bool {
+	for _, thread := range threads {
+		if thread.IsResolved || thread.Line == nil || thread.Path != issue.File {
+			continue
+		}
+		start := *thread.Line
+		if thread.StartLine != nil {
+			start = *thread.StartLine
+		}
+		if linesOverlap(start, *thread.Line, issue.StartLine, issue.EndLine) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/provider"
+)
+
+func TestDropCoveredIssues(t *testing.T) {
+	start, end, resolvedLine := 40, 44, 10
+	threads := []github.ReviewThread{
+		{Path: "auth.go", StartLine: &start, Line: &end},
+		{Path: "auth.go", Line: &resolvedLine, IsResolved: true},
+	}
+	issues := []provider.Issue{
+		{File: "auth.go", StartLine: 42, EndLine: 42, Message: "covered by open thread"},
+		{File: "auth.go", StartLine: 10, EndLine: 10, Message: "thread resolved"},
+		{File: "session.go", StartLine: 42, EndLine: 42, Message: "other file"},
+		{File: "auth.go", StartLine: 45, EndLine: 50, Message: "past the range"},
+	}
+
+	kept, dropped := dropCoveredIssues(issues, threads)
+	if dropped != 1 {
+		t.Fatalf("expected 1 dropped issue, got %d", dropped)
+	}
+	if len(kept) != 3 || kept[0].Message != "thread resolved" {
+		t.Fatalf("unexpected kept issues: %+v", kept)
+	}
+}
+
+func TestRenderExistingComments(t *testing.T) {
+	line := 42
+	threads := []github.ReviewThread{{
+		Path: "auth.go",
+		Line: &line,
+		Comments: []github.ReviewThreadComment{
+			{Author: "reviewer", Body: "Wrap this\nerror."},
+		},
+	}}
+	reviews := []github.Review{
+		{User: github.UserRef{Login: "octo"}, State: "CHANGES_REQUESTED", Body: "Needs docs."},
+		{User: github.UserRef{Login: "octo"}, State: "APPROVED"},
+	}
+
+	out := renderExistingComments(threads, reviews)
+	for _, want := range []string{"- auth.go:42 (unresolved)", "  reviewer: Wrap this error.", "- Review by octo (changes_requested): Needs docs."} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "approved") {
+		t.Fatalf("expected empty review bodies to be skipped:\n%s", out)
+	}
+	if got := renderExistingComments(nil, nil); got != "None" {
+		t.Fatalf("expected None, got %q", got)
+	}
+}
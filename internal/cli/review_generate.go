@@ -2,7 +2,10 @@ package cli
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/brianndofor/prq/internal/diff"
@@ -10,6 +13,7 @@ import (
 	"github.com/brianndofor/prq/internal/prompt"
 	"github.com/brianndofor/prq/internal/provider"
 	"github.com/brianndofor/prq/internal/redact"
+	"github.com/brianndofor/prq/internal/testreport"
 )
 
 type ReviewRun struct {
@@ -18,80 +22,467 @@ type ReviewRun struct {
 	Plan     provider.ReviewPlan
 	Raw      string
 	DiffText string
+	// SinceSHA is the previously reviewed head when only the interdiff was
+	// reviewed; empty for a full review.
+	SinceSHA string
+	// Dropped counts generated issues discarded because an unresolved review
+	// thread already covers the same lines.
+	Dropped int
+	// Stale counts issues from the previous draft discarded by --since-last
+	// because their lines changed or left the diff.
+	Stale int
+	// Tests holds per-command results when --run-tests was used.
+	Tests         []testreport.CommandResult
+	TestSelection string
+	// ToolIssues counts the analyzer findings added to the plan.
+	ToolIssues int
+	// Redactions lists what was hidden from the prompt.
+	Redactions []redact.Finding
+	// Scrubbed counts redacted values the model reproduced that were
+	// replaced with their placeholders again.
+	Scrubbed int
+	// Profile is the review profile applied, if any, and OutOfProfile counts
+	// issues dropped for falling outside its categories.
+	Profile      *reviewProfile
+	OutOfProfile int
 }
 
-func generateReviewPlan(ctx context.Context, app *App, prRef string, maxIssues int, runTests bool) (ReviewRun, error) {
+type reviewOptions struct {
+	maxIssues int
+	runTests  bool
+	// sinceLast reviews only the changes since the last reviewed head and
+	// merges the result into the previous draft.
+	sinceLast bool
+	// context expands hunks with surrounding file contents even when the
+	// repo config leaves context.enabled off.
+	context bool
+	// profile names the review profile; empty selects one by auto_profiles
+	// and "none" disables them.
+	profile string
+	// warn receives warnings such as ignored repo config keys; nil
+	// discards them.
+	warn io.Writer
+}
+
+// reviewInput is everything gathered for a review before the provider runs.
+type reviewInput struct {
+	fullRef  string
+	view     github.PRView
+	diffText string
+	sinceSHA string
+	previous *DraftReviewPayload
+	// stale counts previous issues dropped because their lines changed.
+	stale   int
+	threads []github.ReviewThread
+	// Tests and tool issues come from --run-tests.
+	tests      []testreport.CommandResult
+	selection  string
+	toolIssues []provider.Issue
+	profile    *reviewProfile
+	// prompt is the final, redacted prompt text.
+	prompt string
+	// audit holds the redactions and, in memory only, the redacted values.
+	audit *redact.Audit
+}
+
+// prepareReview fetches the PR, runs local checks if requested, and renders
+// the redacted review prompt.
+func prepareReview(ctx context.Context, app *App, prRef string, opts reviewOptions) (reviewInput, error) {
 	repo, number, err := github.ParsePR(prRef)
 	if err != nil {
-		return ReviewRun{}, err
+		return reviewInput{}, err
 	}
 	fullRef := fmt.Sprintf("%s#%d", repo, number)
 
 	view, err := app.GH.PRView(ctx, fullRef)
 	if err != nil {
-		return ReviewRun{}, err
+		return reviewInput{}, err
 	}
-	diffText, err := app.GH.PRDiff(ctx, fullRef)
-	if err != nil {
-		return ReviewRun{}, err
+	if err := useRepoConfig(ctx, app, repo, view.BaseRefOid, opts.warn); err != nil {
+		return reviewInput{}, err
 	}
 
-	files, err := diff.ParseUnified(diffText)
+	var diffText, diffChunks, fileList, reviewScope, sinceSHA string
+	var previous *DraftReviewPayload
+	var stale int
+	if opts.sinceLast {
+		sinceSHA, err = lastReviewedHead(app, fullRef)
+		if err != nil {
+			return reviewInput{}, err
+		}
+		if sinceSHA == view.HeadRefOid {
+			return reviewInput{}, fmt.Errorf("no new commits on %s since last review at %s", fullRef, sinceSHA)
+		}
+		compare, err := app.GH.CompareCommits(ctx, repo, sinceSHA, view.HeadRefOid)
+		if err != nil {
+			return reviewInput{}, err
+		}
+		diffText = compareUnifiedDiff(compare.Files)
+		diffChunks, err = interdiffChunks(app, compare.Files)
+		if err != nil {
+			return reviewInput{}, err
+		}
+		fileList = renderCompareFileList(compare.Files)
+		reviewScope = fmt.Sprintf("Incremental review: only the changes from %s to %s (%d commits). Earlier code was already reviewed.", sinceSHA, view.HeadRefOid, compare.TotalCommits)
+		previous, err = loadDraftPayload(app, fullRef)
+		if err != nil {
+			return reviewInput{}, err
+		}
+		if previous != nil {
+			fullDiff, err := app.GH.PRDiff(ctx, fullRef)
+			if err != nil {
+				return reviewInput{}, err
+			}
+			files, err := diff.ParseUnified(fullDiff)
+			if err != nil {
+				return reviewInput{}, err
+			}
+			posMap, err := diff.BuildPositionMap(files)
+			if err != nil {
+				return reviewInput{}, err
+			}
+			previous.Plan.Issues, stale = remapIssues(previous.Plan.Issues, compare.Files, posMap)
+		}
+	} else {
+		diffText, err = app.GH.PRDiff(ctx, fullRef)
+		if err != nil {
+			return reviewInput{}, err
+		}
+		files, err := diff.ParseUnified(diffText)
+		if err != nil {
+			return reviewInput{}, err
+		}
+		chunks, err := diff.BuildChunks(files, app.RepoConfig.Diff.Ignore, app.RepoConfig.Diff.MaxFiles, app.RepoConfig.Diff.MaxChunkChars)
+		if err != nil {
+			return reviewInput{}, err
+		}
+		diffChunks = strings.Join(chunks, "\n\n")
+		fileList = renderFileList(view.Files)
+		reviewScope = "Full review of the pull request diff."
+	}
+
+	changed, err := diff.ParseUnified(diffText)
 	if err != nil {
-		return ReviewRun{}, err
+		return reviewInput{}, err
+	}
+	paths := make([]string, 0, len(changed))
+	for _, file := range changed {
+		paths = append(paths, file.Path)
 	}
-	chunks, err := diff.BuildChunks(files, app.RepoConfig.Diff.Ignore, app.RepoConfig.Diff.MaxFiles, app.RepoConfig.Diff.MaxChunkChars)
+	profile, err := selectProfile(app, opts.profile, paths)
 	if err != nil {
-		return ReviewRun{}, err
+		return reviewInput{}, err
+	}
+	rules, err := repoRules(app.RepoConfig, baseGuidelines(ctx, app, repo, view.BaseRefOid), paths)
+	if err != nil {
+		return reviewInput{}, err
+	}
+
+	// The worktree is shared by local context expansion and test runs, and
+	// stays locked until the review input is collected.
+	var worktree *prWorktree
+	defer func() {
+		if worktree != nil {
+			worktree.Release()
+		}
+	}()
+	checkout := func() (*prWorktree, error) {
+		if worktree == nil {
+			wt, err := preparePRWorktree(ctx, app, repo, number, view.HeadRefOid, view.BaseRefOid)
+			if err != nil {
+				return nil, err
+			}
+			worktree = &wt
+		}
+		return worktree, nil
+	}
+
+	// Data that is not collected stays empty, so the template omits it.
+	var codeContext string
+	if opts.context || app.RepoConfig.Context.Enabled {
+		baseRef := view.BaseRefOid
+		if sinceSHA != "" {
+			baseRef = sinceSHA
+		}
+		var src fileSource = contentsSource{gh: app.GH, repo: repo}
+		if app.RepoConfig.Context.Source == "local" {
+			wt, err := checkout()
+			if err != nil {
+				return reviewInput{}, err
+			}
+			src = gitSource{exec: app.Exec, gitDir: wt.GitDir}
+		}
+		codeContext, err = expandContext(ctx, app, src, diffText, baseRef, view.HeadRefOid)
+		if err != nil {
+			return reviewInput{}, err
+		}
 	}
-	fileList := renderFileList(view.Files)
-	diffChunks := strings.Join(chunks, "\n\n")
 
-	testResults := "Not run"
-	if runTests {
-		output, err := runTestsForPR(ctx, app, view)
+	var testResults, relatedCode, analyzerFindings string
+	var tests []testreport.CommandResult
+	var selection string
+	var toolIssues []provider.Issue
+	if opts.runTests {
+		wt, err := checkout()
+		if err != nil {
+			return reviewInput{}, err
+		}
+		checks, err := runLocalChecks(ctx, app, wt.Dir, diffText)
 		if err != nil {
-			return ReviewRun{}, err
+			return reviewInput{}, err
 		}
-		testResults = output
+		testResults, relatedCode, tests, selection = checks.TestSummary, checks.RelatedCode, checks.Tests, checks.Selection
+		analyzerFindings = checks.AnalyzerSummary
+		toolIssues = findingIssues(checks.Findings)
 	}
 
-	redactedTitle := redact.RedactOptional(view.Title, app.Config.Redaction.Enabled)
-	redactedBody := redact.RedactOptional(view.Body, app.Config.Redaction.Enabled)
-	redactedDiff := redact.RedactOptional(diffChunks, app.Config.Redaction.Enabled)
-	redactedFiles := redact.RedactOptional(fileList, app.Config.Redaction.Enabled)
-	redactedUserRules := redact.RedactRuleList(app.Config.UserRules, app.Config.Redaction.Enabled)
-	redactedRepoRules := redact.RedactRuleList(app.RepoConfig.RepoRules, app.Config.Redaction.Enabled)
-	redactedTests := redact.RedactOptional(testResults, app.Config.Redaction.Enabled)
+	threads, err := app.GH.ReviewThreads(ctx, repo, number)
+	if err != nil {
+		return reviewInput{}, err
+	}
+	reviews, err := app.GH.Reviews(ctx, repo, number)
+	if err != nil {
+		return reviewInput{}, err
+	}
+
+	var previousIssues string
+	if previous != nil {
+		previousIssues = renderPreviousIssues(previous.Plan.Issues)
+	}
+
+	audit := redact.NewAudit(app.Redactor, app.Config.Redaction.Enabled)
+	redactedTitle := audit.Redact("title", view.Title)
+	redactedBody := audit.Redact("description", view.Body)
+	redactedDiff := audit.Redact("diff", diffChunks)
+	redactedRelated := audit.Redact("related code", relatedCode)
+	redactedContext := audit.Redact("code context", codeContext)
+	redactedFiles := audit.Redact("file list", fileList)
+	redactedUserRules := audit.RedactLines("user rules", app.Config.UserRules)
+	redactedRepoRules := audit.RedactLines("repo rules", rules)
+	redactedTests := audit.Redact("test results", testResults)
+	redactedFindings := audit.Redact("analyzer findings", analyzerFindings)
+	redactedPrevious := audit.Redact("previous issues", previousIssues)
+	redactedExisting := audit.Redact("existing comments", renderExistingComments(threads, reviews))
+	var profileName string
+	var profileRules []string
+	if profile != nil {
+		profileName = profile.Name
+		profileRules = audit.RedactLines("profile rules", profile.Rules)
+	}
 
 	snap := prompt.Snapshot{
-		Repo:          view.Repository.NameWithOwner,
-		PRNumber:      view.Number,
-		Title:         redactedTitle,
-		Description:   redactedBody,
-		BaseSHA:       view.BaseRefOid,
-		HeadSHA:       view.HeadRefOid,
-		CISummary:     "Not fetched",
-		TestResults:   redactedTests,
-		FileListStats: redactedFiles,
-		DiffChunks:    redactedDiff,
-	}
-
-	template, err := prompt.LoadTemplate()
+		UserRules:        redactedUserRules,
+		RepoRules:        redactedRepoRules,
+		Profile:          profileName,
+		ProfileRules:     profileRules,
+		Repo:             view.Repository.NameWithOwner,
+		PRNumber:         view.Number,
+		Title:            redactedTitle,
+		Description:      redactedBody,
+		BaseSHA:          view.BaseRefOid,
+		HeadSHA:          view.HeadRefOid,
+		ReviewScope:      reviewScope,
+		PreviousIssues:   redactedPrevious,
+		ExistingComments: redactedExisting,
+		TestResults:      redactedTests,
+		AnalyzerFindings: redactedFindings,
+		FileListStats:    redactedFiles,
+		DiffChunks:       redactedDiff,
+		CodeContext:      redactedContext,
+		RelatedCode:      redactedRelated,
+	}
+
+	var template prompt.Asset
+	if profile != nil && profile.Prompt != "" {
+		template, err = profileAsset(ctx, app, repo, view.BaseRefOid, prompt.ReviewPromptSpec, profile.Prompt)
+	} else {
+		template, err = prompt.LoadTemplate()
+	}
+	if err != nil {
+		return reviewInput{}, err
+	}
+	promptText, err := prompt.Render(string(template.Content), snap)
+	if err != nil {
+		return reviewInput{}, err
+	}
+	promptText = audit.RedactPromptBlock(promptText)
+
+	return reviewInput{
+		fullRef:    fullRef,
+		view:       view,
+		diffText:   diffText,
+		sinceSHA:   sinceSHA,
+		previous:   previous,
+		stale:      stale,
+		threads:    threads,
+		tests:      tests,
+		selection:  selection,
+		toolIssues: toolIssues,
+		profile:    profile,
+		prompt:     promptText,
+		audit:      audit,
+	}, nil
+}
+
+func generateReviewPlan(ctx context.Context, app *App, prRef string, opts reviewOptions) (ReviewRun, error) {
+	in, err := prepareReview(ctx, app, prRef, opts)
 	if err != nil {
 		return ReviewRun{}, err
 	}
-	promptText := prompt.Render(template, redactedUserRules, redactedRepoRules, snap)
-	promptText = redact.RedactPromptBlock(promptText, app.Config.Redaction.Enabled)
 
-	schemaPath := prompt.DefaultSchemaPath()
-	plan, raw, err := app.Provider.RunReview(ctx, promptText, schemaPath)
+	runner := app.Provider
+	var schema prompt.Asset
+	if in.profile != nil && in.profile.Schema != "" {
+		schema, err = profileAsset(ctx, app, in.view.Repository.NameWithOwner, in.view.BaseRefOid, prompt.ReviewSchemaSpec, in.profile.Schema)
+	} else {
+		schema, err = prompt.LoadSchema()
+	}
+	if err != nil {
+		return ReviewRun{}, err
+	}
+	if in.profile != nil {
+		runner = provider.WithArgs(runner, in.profile.ProviderArgs)
+	}
+	plan, raw, err := runner.RunReview(ctx, in.prompt, schema.Content)
 	if err != nil {
 		return ReviewRun{}, err
 	}
-	if maxIssues > 0 && len(plan.Issues) > maxIssues {
-		plan.Issues = plan.Issues[:maxIssues]
+	var dropped int
+	plan.Issues, dropped = dropCoveredIssues(plan.Issues, in.threads)
+	if dropped > 0 {
+		// raw still contains the dropped issues.
+		raw = ""
+	}
+	toolIssues := in.toolIssues
+	var outOfProfile int
+	if in.profile != nil {
+		var droppedModel, droppedTools int
+		plan.Issues, droppedModel = filterCategories(plan.Issues, in.profile.Categories)
+		toolIssues, droppedTools = filterCategories(toolIssues, in.profile.Categories)
+		outOfProfile = droppedModel + droppedTools
+		if droppedModel > 0 {
+			raw = ""
+		}
+	}
+	if opts.maxIssues > 0 && len(plan.Issues) > opts.maxIssues {
+		plan.Issues = plan.Issues[:opts.maxIssues]
+	}
+	if len(toolIssues) > 0 {
+		// Analyzer findings bypass --max-issues but not open threads.
+		var droppedTools int
+		toolIssues, droppedTools = dropCoveredIssues(toolIssues, in.threads)
+		dropped += droppedTools
+		if len(toolIssues) > 0 {
+			plan.Issues = append(plan.Issues, toolIssues...)
+			raw = ""
+		}
+	}
+	var scrubbed int
+	plan, scrubbed = scrubPlan(in.audit, plan)
+	if scrubbed > 0 {
+		raw = ""
+	}
+	if in.previous != nil {
+		plan = mergeIncrementalPlan(in.previous.Plan, plan)
+		// raw describes only the incremental run; callers print the merged plan.
+		raw = ""
 	}
 
-	return ReviewRun{FullRef: fullRef, View: view, Plan: plan, Raw: raw, DiffText: diffText}, nil
+	return ReviewRun{
+		FullRef:       in.fullRef,
+		View:          in.view,
+		Plan:          plan,
+		Raw:           raw,
+		DiffText:      in.diffText,
+		SinceSHA:      in.sinceSHA,
+		Dropped:       dropped,
+		Stale:         in.stale,
+		Tests:         in.tests,
+		TestSelection: in.selection,
+		ToolIssues:    len(toolIssues),
+		Redactions:    in.audit.Findings,
+		Scrubbed:      scrubbed,
+		Profile:       in.profile,
+		OutOfProfile:  outOfProfile,
+	}, nil
+}
+
+func lastReviewedHead(app *App, fullRef string) (string, error) {
+	state, err := app.Store.GetPR(fullRef)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if err == sql.ErrNoRows || !state.LastReviewedHeadSHA.Valid || state.LastReviewedHeadSHA.String == "" {
+		return "", fmt.Errorf("no previous review recorded for %s; run without --since-last first", fullRef)
+	}
+	return state.LastReviewedHeadSHA.String, nil
+}
+
+// loadDraftPayload returns the saved draft for fullRef, or nil if none exists.
+func loadDraftPayload(app *App, fullRef string) (*DraftReviewPayload, error) {
+	draft, err := app.Store.GetDraftReview(fullRef)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var payload DraftReviewPayload
+	if err := json.Unmarshal([]byte(draft.PayloadJSON), &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode saved draft payload: %w", err)
+	}
+	return &payload, nil
+}
+
+func renderCompareFileList(files []github.CompareFile) string {
+	if len(files) == 0 {
+		return "No files"
+	}
+	var b strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&b, "%s (%s +%d/-%d)\n", file.Filename, file.Status, file.Additions, file.Deletions)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func renderPreviousIssues(issues []provider.Issue) string {
+	if len(issues) == 0 {
+		return "None"
+	}
+	var b strings.Builder
+	for _, issue := range issues {
+		b.WriteString("- ")
+		b.WriteString(renderIssueSummary(issue))
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// scrubPlan replaces redacted values reproduced anywhere in the plan, including
+// analyzer findings built from unredacted output, with their placeholders.
+func scrubPlan(audit *redact.Audit, plan provider.ReviewPlan) (provider.ReviewPlan, int) {
+	total := 0
+	scrub := func(text *string) {
+		var n int
+		*text, n = audit.Scrub(*text)
+		total += n
+	}
+	scrubAll := func(items []string) []string {
+		out := append([]string(nil), items...)
+		for i := range out {
+			scrub(&out[i])
+		}
+		return out
+	}
+	scrub(&plan.Summary)
+	scrub(&plan.DraftReviewBody)
+	plan.KeyChanges = scrubAll(plan.KeyChanges)
+	plan.Questions = scrubAll(plan.Questions)
+	plan.Praise = scrubAll(plan.Praise)
+	plan.Issues = append([]provider.Issue(nil), plan.Issues...)
+	for i := range plan.Issues {
+		scrub(&plan.Issues[i].Message)
+		scrub(&plan.Issues[i].SuggestionPatch)
+	}
+	return plan, total
 }
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/brianndofor/prq/internal/diff"
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/provider"
+)
+
+// mergeIncrementalPlan folds an incremental review of new commits into the
+// previous draft. Earlier issues are kept, new issues that repeat an earlier
+// one are dropped, and risk and decision take the more severe of the two.
+func mergeIncrementalPlan(previous provider.ReviewPlan, incremental provider.ReviewPlan) provider.ReviewPlan {
+	merged := previous
+
+	switch {
+	case strings.TrimSpace(previous.Summary) == "":
+		merged.Summary = incremental.Summary
+	case strings.TrimSpace(incremental.Summary) != "":
+		merged.Summary = strings.TrimSpace(previous.Summary) + "\n\nSince last review: " + strings.TrimSpace(incremental.Summary)
+	}
+	if riskRank(incremental.RiskLevel) > riskRank(previous.RiskLevel) {
+		merged.RiskLevel = incremental.RiskLevel
+	}
+	if decisionRank(incremental.Decision) > decisionRank(previous.Decision) {
+		merged.Decision = incremental.Decision
+	}
+
+	merged.KeyChanges = appendUnique(previous.KeyChanges, incremental.KeyChanges)
+	merged.Questions = appendUnique(previous.Questions, incremental.Questions)
+	merged.Praise = appendUnique(previous.Praise, incremental.Praise)
+
+	merged.Issues = append([]provider.Issue{}, previous.Issues...)
+	for _, issue := range incremental.Issues {
+		if !duplicatesIssue(previous.Issues, issue) {
+			merged.Issues = append(merged.Issues, issue)
+		}
+	}
+
+	switch {
+	case strings.TrimSpace(previous.DraftReviewBody) == "":
+		merged.DraftReviewBody = incremental.DraftReviewBody
+	case strings.TrimSpace(incremental.DraftReviewBody) != "":
+		merged.DraftReviewBody = strings.TrimSpace(previous.DraftReviewBody) + "\n\nUpdate since last review:\n" + strings.TrimSpace(incremental.DraftReviewBody)
+	}
+	return merged
+}
+
+// remapIssues moves issues from the previously reviewed head to the current
+// one through the files changed since, following renames. Issues whose
+// lines were changed, or that no longer land on a line of the PR diff, are
+// dropped and counted.
+func remapIssues(issues []provider.Issue, changed []github.CompareFile, posMap diff.PositionMap) ([]provider.Issue, int) {
+	byPath := map[string]github.CompareFile{}
+	for _, file := range changed {
+		byPath[file.Filename] = file
+		if file.PreviousFilename != "" {
+			byPath[file.PreviousFilename] = file
+		}
+	}
+	kept := make([]provider.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if file, ok := byPath[issue.File]; ok {
+			if file.Status == "removed" {
+				continue
+			}
+			start, ok := diff.MapLine(file.Patch, issue.StartLine)
+			if !ok {
+				continue
+			}
+			if issue.EndLine > issue.StartLine {
+				end, ok := diff.MapLine(file.Patch, issue.EndLine)
+				if !ok {
+					continue
+				}
+				issue.EndLine = end
+			} else if issue.EndLine != 0 {
+				issue.EndLine = start
+			}
+			issue.StartLine = start
+			issue.File = file.Filename
+		}
+		if _, ok := posMap.PositionForNewLine(issue.File, issue.StartLine); !ok {
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept, len(issues) - len(kept)
+}
+
+// duplicatesIssue reports whether issue repeats one of existing: same file
+// and category with overlapping line ranges.
+func duplicatesIssue(existing []provider.Issue, issue provider.Issue) bool {
+	for _, prev := range existing {
+		if prev.File == issue.File && prev.Category == issue.Category && linesOverlap(prev.StartLine, prev.EndLine, issue.StartLine, issue.EndLine) {
+			return true
+		}
+	}
+	return false
+}
+
+func linesOverlap(aStart, aEnd, bStart, bEnd int) bool {
+	if aEnd < aStart {
+		aEnd = aStart
+	}
+	if bEnd < bStart {
+		bEnd = bStart
+	}
+	return aStart <= bEnd && bStart <= aEnd
+}
+
+func appendUnique(base []string, extra []string) []string {
+	out := append([]string{}, base...)
+	seen := map[string]bool{}
+	for _, item := range base {
+		seen[strings.TrimSpace(item)] = true
+	}
+	for _, item := range extra {
+		key := strings.TrimSpace(item)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, item)
+	}
+	return out
+}
+
+func riskRank(risk string) int {
+	switch risk {
+	case "high":
+		return 2
+	case "medium":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func decisionRank(decision string) int {
+	switch decision {
+	case "request_changes":
+		return 2
+	case "comment":
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianndofor/prq/internal/diff"
+	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/provider"
+)
+
+func TestMergeIncrementalPlan(t *testing.T) {
+	previous := provider.ReviewPlan{
+		Summary:         "Adds login.",
+		RiskLevel:       "medium",
+		Decision:        "comment",
+		KeyChanges:      []string{"Adds Login"},
+		Issues:          []provider.Issue{{Severity: "major", Category: "correctness", File: "auth.go", StartLine: 10, EndLine: 14, Message: "Missing nil check"}},
+		DraftReviewBody: "Looks close.",
+	}
+	incremental := provider.ReviewPlan{
+		Summary:   "Wraps errors.",
+		RiskLevel: "low",
+		Decision:  "request_changes",
+		KeyChanges: []string{
+			"Adds Login",
+			"Wraps login errors",
+		},
+		Issues: []provider.Issue{
+			{Severity: "major", Category: "correctness", File: "auth.go", StartLine: 12, EndLine: 12, Message: "Still missing nil check"},
+			{Severity: "minor", Category: "tests", File: "auth_test.go", StartLine: 3, EndLine: 3, Message: "Add a test"},
+		},
+		DraftReviewBody: "One new concern.",
+	}
+
+	merged := mergeIncrementalPlan(previous, incremental)
+	if merged.RiskLevel != "medium" {
+		t.Fatalf("expected risk to stay medium, got %q", merged.RiskLevel)
+	}
+	if merged.Decision != "request_changes" {
+		t.Fatalf("expected stricter decision, got %q", merged.Decision)
+	}
+	if len(merged.KeyChanges) != 2 {
+		t.Fatalf("expected de-duplicated key changes, got %v", merged.KeyChanges)
+	}
+	if len(merged.Issues) != 2 || merged.Issues[1].File != "auth_test.go" {
+		t.Fatalf("expected overlapping issue to be dropped, got %#v", merged.Issues)
+	}
+	if !strings.Contains(merged.Summary, "Since last review: Wraps errors.") {
+		t.Fatalf("unexpected summary: %q", merged.Summary)
+	}
+	if !strings.Contains(merged.DraftReviewBody, "Update since last review:\nOne new concern.") {
+		t.Fatalf("unexpected body: %q", merged.DraftReviewBody)
+	}
+}
+
+func TestCompareUnifiedDiff(t *testing.T) {
+	out := compareUnifiedDiff([]github.CompareFile{
+		{Filename: "new.go", PreviousFilename: "old.go", Status: "renamed", Patch: "@@ -1 +1 @@\n-a\n+b"},
+		{Filename: "logo.png", Status: "added"},
+	})
+	if !strings.Contains(out, "diff --git a/old.go b/new.go\n--- a/old.go\n+++ b/new.go\n@@ -1 +1 @@\n-a\n+b\n") {
+		t.Fatalf("unexpected patch output: %q", out)
+	}
+	if !strings.Contains(out, "diff --git a/logo.png b/logo.png\n(added; patch unavailable)\n") {
+		t.Fatalf("expected note for missing patch: %q", out)
+	}
+}
+
+func TestRemapIssues(t *testing.T) {
+	changed := []github.CompareFile{{
+		Filename:         "auth/login.go",
+		PreviousFilename: "auth/auth.go",
+		Status:           "renamed",
+		Patch:            "@@ -1,3 +1,5 @@\n-func Login(user string) error {\n+// Login authenticates user.\n+func Login(user string) error {\n+\t_ = user\n \treturn nil\n }",
+	}}
+	prDiff := "diff --git a/auth/login.go b/auth/login.go\n--- a/auth/login.go\n+++ b/auth/login.go\n@@ -1,3 +1,5 @@\n+// Login authenticates user.\n func Login(user string) error {\n+\t_ = user\n \treturn nil\n }\n"
+	files, err := diff.ParseUnified(prDiff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	posMap, err := diff.BuildPositionMap(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := []provider.Issue{
+		{File: "auth/auth.go", StartLine: 2, EndLine: 3, Message: "moved"},
+		{File: "auth/auth.go", StartLine: 1, EndLine: 1, Message: "rewritten"},
+		{File: "docs/notes.md", StartLine: 4, EndLine: 4, Message: "not in diff"},
+	}
+	kept, dropped := remapIssues(issues, changed, posMap)
+	if dropped != 2 || len(kept) != 1 {
+		t.Fatalf("expected one issue kept and two dropped, got %d dropped: %+v", dropped, kept)
+	}
+	if kept[0].File != "auth/login.go" || kept[0].StartLine != 4 || kept[0].EndLine != 5 {
+		t.Fatalf("expected the issue to follow the rename and shift, got %+v", kept[0])
+	}
+}
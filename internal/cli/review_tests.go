@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/brianndofor/prq/internal/testreport"
+	"github.com/spf13/cobra"
+)
+
+// writeTestTable prints the package selection, if any, and a pass/fail row
+// per test command, followed by the parsed failing tests.
+func writeTestTable(cmd *cobra.Command, results []testreport.CommandResult, selection string, markdown bool) {
+	out := cmd.OutOrStdout()
+	if markdown {
+		fmt.Fprintln(out, "## Tests")
+		if selection != "" {
+			fmt.Fprintf(out, "%s\n\n", selection)
+		}
+		fmt.Fprintln(out, "| Command | Result | Exit | Duration | Tests |")
+		fmt.Fprintln(out, "| --- | --- | --- | --- | --- |")
+		for _, r := range results {
+			fmt.Fprintf(out, "| `%s` | %s | %d | %s | %s |\n", r.Command, r.Status(), r.ExitCode, testreport.FormatDuration(r.Duration), testCounts(r))
+		}
+		for _, r := range results {
+			for _, f := range r.Failures {
+				fmt.Fprintf(out, "- **FAIL** `%s`\n", f.Name())
+			}
+		}
+		fmt.Fprintln(out)
+		return
+	}
+
+	fmt.Fprintf(out, "\n%s%s══ Tests ══%s\n\n", colorBold, colorBlue, colorReset)
+	if selection != "" {
+		fmt.Fprintf(out, "  %s%s%s\n\n", colorDim, selection, colorReset)
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		mark, color := "✓", colorGreen
+		if !r.Passed() {
+			mark, color = "✗", colorRed
+		}
+		fmt.Fprintf(tw, "  %s%s %s%s\t%s\texit %d\t%s\t%s\n", color, mark, r.Status(), colorReset, r.Command, r.ExitCode, testreport.FormatDuration(r.Duration), testCounts(r))
+	}
+	_ = tw.Flush()
+	for _, r := range results {
+		for _, f := range r.Failures {
+			fmt.Fprintf(out, "     %sFAIL%s %s\n", colorRed, colorReset, f.Name())
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+func testCounts(r testreport.CommandResult) string {
+	if r.Tests == nil {
+		return "-"
+	}
+	parts := []string{fmt.Sprintf("%d passed", r.Tests.Passed)}
+	if r.Tests.Failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", r.Tests.Failed))
+	}
+	if r.Tests.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", r.Tests.Skipped))
+	}
+	return strings.Join(parts, ", ")
+}
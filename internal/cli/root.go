@@ -34,6 +34,8 @@ func NewRootCmd() *cobra.Command {
 	root.AddCommand(NewSubmitCmd())
 	root.AddCommand(NewFollowupCmd())
 	root.AddCommand(NewConfigCmd())
+	root.AddCommand(NewGCCmd())
+	root.AddCommand(NewRedactCmd())
 
 	return root
 }
@@ -5,53 +5,222 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
-	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/analysis"
+	"github.com/brianndofor/prq/internal/diff"
+	"github.com/brianndofor/prq/internal/related"
+	"github.com/brianndofor/prq/internal/testreport"
 )
 
-func runTestsForPR(ctx context.Context, app *App, view github.PRView) (string, error) {
+// noTestCommands is the test summary when ./prq.yaml has no tests.commands;
+// commands committed to the reviewed repo are never run.
+const noTestCommands = "No test commands configured. Test commands are only taken from ./prq.yaml, not from the reviewed repo's base branch."
+
+// localChecks is what runLocalChecks collects from a PR worktree.
+type localChecks struct {
+	Tests []testreport.CommandResult
+	// Selection describes the packages substituted for {PACKAGES}; empty
+	// when no command uses the placeholder and tests.mode is all.
+	Selection string
+	// TestSummary is the compact form of Tests used in the prompt.
+	TestSummary string
+	RelatedCode string
+	// Findings are analyzer findings on changed lines; AnalyzerSummary is
+	// their prompt form, including analyzers that failed to run.
+	Findings        []analysis.Finding
+	AnalyzerSummary string
+}
+
+// runLocalChecks runs the configured test commands and analyzers in a PR
+// worktree and collects related Go code for the symbols changed by diffText.
+func runLocalChecks(ctx context.Context, app *App, repoDir string, diffText string) (localChecks, error) {
+	checks := localChecks{TestSummary: noTestCommands, RelatedCode: "Not collected", AnalyzerSummary: noAnalyzers}
+	if commands := testCommands(app); len(commands) > 0 {
+		sel := selectTestPackages(ctx, app, repoDir, diffText)
+		results, err := runTestCommands(ctx, app, repoDir, commands, sel)
+		if err != nil {
+			return localChecks{}, err
+		}
+		checks.Tests = results
+		checks.TestSummary = testreport.Summarize(results)
+		if app.RepoConfig.Tests.Mode == "affected" || usesPackages(commands) {
+			checks.Selection = sel.String()
+			checks.TestSummary = "Test selection: " + checks.Selection + "\n\n" + checks.TestSummary
+		}
+	}
+	if len(app.RepoConfig.Analyzers) > 0 {
+		findings, notes, err := runAnalyzers(ctx, app, repoDir, diffText)
+		if err != nil {
+			return localChecks{}, err
+		}
+		checks.Findings = findings
+		checks.AnalyzerSummary = renderAnalyzerFindings(findings, notes)
+	}
+	if app.RepoConfig.Context.Related {
+		relatedCode, err := relatedGoCode(app, repoDir, diffText)
+		if err != nil {
+			return localChecks{}, err
+		}
+		checks.RelatedCode = relatedCode
+	}
+	return checks, nil
+}
+
+func testCommands(app *App) []string {
 	commands := make([]string, 0, len(app.RepoConfig.Tests.Commands))
 	for _, cmd := range app.RepoConfig.Tests.Commands {
 		if strings.TrimSpace(cmd) != "" {
 			commands = append(commands, cmd)
 		}
 	}
-	if len(commands) == 0 {
-		return "No test commands configured in prq.yaml.", nil
+	return commands
+}
+
+func usesPackages(commands []string) bool {
+	for _, command := range commands {
+		if strings.Contains(command, packagesPlaceholder) {
+			return true
+		}
 	}
+	return false
+}
 
-	workDir, err := os.MkdirTemp("", "prq-tests-*")
+// runTestCommands runs each command in repoDir with the allowlisted
+// environment, the configured timeout and output cap, and the sandbox if one
+// is configured. {PACKAGES} is replaced with sel; a command using it is
+// skipped when sel is empty. `go test -json` output and JUnit reports written to
+// tests.junit_paths are parsed into test counts and failures. A failing
+// command is recorded in its result, not returned as an error.
+func runTestCommands(ctx context.Context, app *App, repoDir string, commands []string, sel testSelection) ([]testreport.CommandResult, error) {
+	cfg := app.RepoConfig.Tests
+	timeout, err := testTimeout(cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp dir: %w", err)
+		return nil, err
 	}
-	defer func() {
-		_ = os.RemoveAll(workDir)
-	}()
+	baseEnv := testEnv(os.Environ(), cfg.EnvAllowlist)
 
-	repoDir := filepath.Join(workDir, "repo")
-	if _, err := app.Exec.Run(ctx, "", "gh", "repo", "clone", view.Repository.NameWithOwner, repoDir); err != nil {
-		return "", fmt.Errorf("failed to clone repo: %w", err)
-	}
-	if _, err := app.Exec.Run(ctx, repoDir, "gh", "pr", "checkout", strconv.Itoa(view.Number)); err != nil {
-		return "", fmt.Errorf("failed to checkout PR: %w", err)
+	results := make([]testreport.CommandResult, 0, len(commands))
+	for _, command := range commands {
+		run := command
+		if strings.Contains(command, packagesPlaceholder) {
+			if sel.args() == "" {
+				results = append(results, testreport.CommandResult{Command: command, SkipReason: "no affected Go packages"})
+				continue
+			}
+			run = strings.ReplaceAll(command, packagesPlaceholder, sel.args())
+		}
+		name, args, env, err := testInvocation(cfg, repoDir, run, baseEnv)
+		if err != nil {
+			return nil, err
+		}
+		opts := ExecOptions{Env: env, Timeout: timeout, MaxOutputBytes: cfg.MaxOutputBytes}
+		var parser *testreport.GoTestParser
+		if testreport.IsGoTestJSON(run) {
+			parser = testreport.NewGoTestParser()
+			opts.StdoutSink = parser
+		}
+		started := time.Now()
+		res, err := app.Exec.RunLimited(ctx, repoDir, opts, name, args...)
+		result := testreport.CommandResult{
+			Command:  command,
+			ExitCode: res.ExitCode,
+			Duration: res.Duration,
+			TimedOut: res.TimedOut,
+			Stdout:   res.Stdout,
+			Stderr:   res.Stderr,
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if parser != nil {
+			if counts, failures, ok := parser.Result(); ok {
+				result.Merge(testreport.FormatGoTestJSON, counts, failures)
+			}
+		}
+		if err := mergeJUnitReports(&result, repoDir, cfg.JUnitPaths, started); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
 	}
+	return results, nil
+}
 
-	var output strings.Builder
-	for _, cmd := range commands {
-		fmt.Fprintf(&output, "$ %s\n", cmd)
-		cmdOutput, err := app.Exec.Run(ctx, repoDir, "sh", "-lc", cmd)
-		if cmdOutput != "" {
-			output.WriteString(cmdOutput)
-			if !strings.HasSuffix(cmdOutput, "\n") {
-				output.WriteString("\n")
+// mergeJUnitReports parses JUnit files matching globs (relative to repoDir)
+// that were written since started, attributing them to result.
+func mergeJUnitReports(result *testreport.CommandResult, repoDir string, globs []string, started time.Time) error {
+	for _, glob := range globs {
+		matches, err := filepath.Glob(filepath.Join(repoDir, glob))
+		if err != nil {
+			return fmt.Errorf("invalid tests.junit_paths entry %q: %w", glob, err)
+		}
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() || info.ModTime().Before(started) {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read junit report: %w", err)
+			}
+			counts, failures, err := testreport.ParseJUnit(data)
+			if err != nil {
+				// A half-written or foreign file should not sink the whole run.
+				result.Stderr += fmt.Sprintf("\nprq: could not parse %s: %v\n", filepath.Base(path), err)
+				continue
 			}
+			result.Merge(testreport.FormatJUnit, counts, failures)
 		}
-		if err != nil {
-			fmt.Fprintf(&output, "Command failed: %v\n", err)
+	}
+	return nil
+}
+
+// relatedGoCode lists the definitions and references of exported Go
+// identifiers whose declarations the diff touches.
+func relatedGoCode(app *App, repoDir string, diffText string) (string, error) {
+	files, err := diff.ParseUnified(diffText)
+	if err != nil {
+		return "", err
+	}
+	changes := map[string][]diff.LineRange{}
+	for _, file := range diff.ContextFiles(files, app.RepoConfig.Diff.Ignore, app.RepoConfig.Diff.MaxFiles) {
+		if file.FileStatus() == "removed" {
+			continue
 		}
+		changes[file.Path] = diff.ChangedLines(file)
+	}
+	symbols, err := related.ChangedSymbols(repoDir, changes)
+	if err != nil {
+		return "", err
+	}
+	refs, err := related.FindReferences(repoDir, symbols, related.Options{
+		MaxReferences: app.RepoConfig.Context.MaxReferences,
+		Ignore:        app.RepoConfig.Diff.Ignore,
+	})
+	if err != nil {
+		return "", err
+	}
+	out := related.Render(symbols, refs)
+	if len(out) > app.RepoConfig.Diff.MaxChunkChars {
+		out = truncateText(out, app.RepoConfig.Diff.MaxChunkChars) + "\n... (truncated)"
 	}
+	return out, nil
+}
 
-	return strings.TrimSpace(output.String()), nil
+// truncateText cuts text to at most limit bytes, at the last line break
+// when there is one and otherwise at a rune boundary.
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := text[:limit]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		return cut[:i]
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
 }
@@ -1,43 +1,102 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/brianndofor/prq/internal/config"
-	"github.com/brianndofor/prq/internal/github"
+	"github.com/brianndofor/prq/internal/testreport"
+	"github.com/spf13/cobra"
 )
 
-func TestRunTestsNoCommands(t *testing.T) {
-	app := &App{
-		RepoConfig: config.RepoConfig{Tests: config.TestsConfig{Commands: []string{}}},
-		Exec:       FakeExecRunner{},
+func TestRunTestCommandsExecutesCommands(t *testing.T) {
+	app := &App{RepoConfig: config.DefaultRepoConfig(), Exec: FakeExecRunner{}}
+	results, err := runTestCommands(context.Background(), app, t.TempDir(), []string{"echo ok"}, testSelection{All: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := testreport.Summarize(results)
+	if !strings.Contains(output, "$ echo ok") {
+		t.Fatalf("expected output to include command, got: %q", output)
+	}
+	if !strings.Contains(output, "mock command output") {
+		t.Fatalf("expected mock output, got: %q", output)
 	}
-	view := github.PRView{Repository: github.RepoRef{NameWithOwner: "acme/app"}, Number: 42}
-	output, err := runTestsForPR(context.Background(), app, view)
+}
+
+func TestTruncateText(t *testing.T) {
+	if got := truncateText("one\ntwo\nthree", 9); got != "one\ntwo" {
+		t.Fatalf("expected a cut at the line break, got %q", got)
+	}
+	if got := truncateText("héllo", 2); got != "h" {
+		t.Fatalf("expected a cut at a rune boundary, got %q", got)
+	}
+}
+
+func TestRunLocalChecksCollectsRelatedCode(t *testing.T) {
+	app := &App{RepoConfig: config.DefaultRepoConfig(), Exec: FakeExecRunner{}}
+	app.RepoConfig.Tests.Commands = []string{"echo ok"}
+	diffText := "diff --git a/auth.go b/auth.go\n--- a/auth.go\n+++ b/auth.go\n@@ -1 +1 @@\n-a\n+b\n"
+
+	checks, err := runLocalChecks(context.Background(), app, t.TempDir(), diffText)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if output != "No test commands configured in prq.yaml." {
-		t.Fatalf("unexpected output: %q", output)
+	if len(checks.Tests) != 1 || checks.Tests[0].Status() != "PASS" || !strings.Contains(checks.TestSummary, "$ echo ok") {
+		t.Fatalf("unexpected test results: %+v", checks)
+	}
+	// The checkout is empty, so no changed symbols are found.
+	if checks.RelatedCode != "None" {
+		t.Fatalf("unexpected related code: %q", checks.RelatedCode)
+	}
+
+	app.RepoConfig.Context.Related = false
+	app.RepoConfig.Tests.Commands = nil
+	checks, err = runLocalChecks(context.Background(), app, t.TempDir(), diffText)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if checks.TestSummary != noTestCommands || checks.RelatedCode != "Not collected" || checks.Tests != nil {
+		t.Fatalf("unexpected results: %+v", checks)
 	}
 }
 
-func TestRunTestsExecutesCommands(t *testing.T) {
-	app := &App{
-		RepoConfig: config.RepoConfig{Tests: config.TestsConfig{Commands: []string{"echo ok"}}},
-		Exec:       FakeExecRunner{},
+func TestRunTestCommandsParsesJUnit(t *testing.T) {
+	repoDir := t.TempDir()
+	app := &App{RepoConfig: config.DefaultRepoConfig(), Exec: FakeExecRunner{}}
+	app.RepoConfig.Tests.JUnitPaths = []string{"reports/*.xml"}
+	if err := os.MkdirAll(filepath.Join(repoDir, "reports"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	// The fake runner does not run anything, so write the report up front
+	// with a future timestamp to count as produced by the command.
+	report := filepath.Join(repoDir, "reports", "junit.xml")
+	if err := os.WriteFile(report, []byte(`<testsuite name="api"><testcase name="ok"/><testcase name="bad"><failure message="nope"/></testcase></testsuite>`), 0o644); err != nil {
+		t.Fatalf("write report: %v", err)
 	}
-	view := github.PRView{Repository: github.RepoRef{NameWithOwner: "acme/app"}, Number: 42}
-	output, err := runTestsForPR(context.Background(), app, view)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(report, future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	results, err := runTestCommands(context.Background(), app, repoDir, []string{"make test"}, testSelection{All: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !strings.Contains(output, "$ echo ok") {
-		t.Fatalf("expected output to include command, got: %q", output)
+	if len(results) != 1 || results[0].Format != "junit" || results[0].Status() != "FAIL" || len(results[0].Failures) != 1 {
+		t.Fatalf("unexpected results: %+v", results)
 	}
-	if !strings.Contains(output, "mock command output") {
-		t.Fatalf("expected mock output, got: %q", output)
+
+	cmd := &cobra.Command{}
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	writeTestTable(cmd, results, "", true)
+	if !strings.Contains(out.String(), "| `make test` | FAIL | 0 |") || !strings.Contains(out.String(), "1 passed, 1 failed") || !strings.Contains(out.String(), "- **FAIL** `api bad`") {
+		t.Fatalf("unexpected table:\n%s", out.String())
 	}
 }
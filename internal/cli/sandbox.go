@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/brianndofor/prq/internal/config"
+)
+
+// credentialMarkers are substrings of environment variable names that are
+// never passed to test commands, even when allowlisted.
+var credentialMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "API_KEY", "PRIVATE_KEY"}
+
+// testEnv filters environ down to the allowlisted names, dropping anything
+// that looks like a credential.
+func testEnv(environ []string, allowlist []string) []string {
+	if len(allowlist) == 0 {
+		allowlist = config.DefaultTestEnvAllowlist()
+	}
+	allowed := map[string]bool{}
+	for _, name := range allowlist {
+		if !looksLikeCredential(name) {
+			allowed[name] = true
+		}
+	}
+	env := []string{}
+	for _, entry := range environ {
+		name, _, ok := strings.Cut(entry, "=")
+		if ok && allowed[name] {
+			env = append(env, entry)
+		}
+	}
+	return env
+}
+
+func looksLikeCredential(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range credentialMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// testInvocation returns the program and arguments that run command in
+// repoDir under the configured sandbox, and the environment to use.
+func testInvocation(cfg config.TestsConfig, repoDir string, command string, env []string) (string, []string, []string, error) {
+	switch cfg.Sandbox {
+	case "", "none":
+		return "sh", []string{"-c", command}, env, nil
+	case "bwrap":
+		if runtime.GOOS != "linux" {
+			return "", nil, nil, fmt.Errorf("tests.sandbox bwrap requires Linux")
+		}
+		if _, err := exec.LookPath("bwrap"); err != nil {
+			return "", nil, nil, fmt.Errorf("tests.sandbox bwrap: bubblewrap not found in PATH")
+		}
+		// The host filesystem, home included, is read-only with credential
+		// files masked; build caches go to the private /tmp.
+		home, _ := os.UserHomeDir()
+		env = append(env, "TMPDIR=/tmp", "GOCACHE=/tmp/go-build", "XDG_CACHE_HOME=/tmp/.cache")
+		args := bwrapArgs(home, repoDir, command)
+		return "bwrap", args, env, nil
+	default:
+		return "", nil, nil, fmt.Errorf("invalid tests.sandbox %q", cfg.Sandbox)
+	}
+}
+
+// credentialDirs and credentialFiles under home are masked in the bwrap
+// sandbox: directories with an empty tmpfs, files with /dev/null.
+var (
+	credentialDirs  = []string{".ssh", ".gnupg", ".aws", ".azure", ".kube", ".docker", ".config/gh", ".config/gcloud", ".config/hub"}
+	credentialFiles = []string{".netrc", ".git-credentials", ".npmrc", ".pypirc", ".config/git/credentials"}
+)
+
+// bwrapArgs mounts the host read-only, masks the credential files and
+// directories that exist under home, and makes only repoDir and a private
+// /tmp writable. The worktree's git metadata and the Go module cache are
+// bound back read-only in case they live under a masked directory.
+func bwrapArgs(home, repoDir, command string) []string {
+	args := []string{
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+	if home != "" && home != "/" {
+		for _, name := range credentialDirs {
+			path := filepath.Join(home, filepath.FromSlash(name))
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				args = append(args, "--tmpfs", path)
+			}
+		}
+		for _, name := range credentialFiles {
+			path := filepath.Join(home, filepath.FromSlash(name))
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				args = append(args, "--ro-bind", os.DevNull, path)
+			}
+		}
+	}
+	for _, dir := range append(worktreeGitDirs(repoDir), goModCache(home)) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			args = append(args, "--ro-bind", dir, dir)
+		}
+	}
+	return append(args,
+		"--bind", repoDir, repoDir,
+		"--chdir", repoDir,
+		"--unshare-all",
+		"--die-with-parent",
+		"--new-session",
+		"--", "sh", "-c", command,
+	)
+}
+
+// worktreeGitDirs returns the git directories a linked worktree at dir
+// points to: its own gitdir and the repository's common dir. It returns nil
+// for anything else.
+func worktreeGitDirs(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return nil
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return nil
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	dirs := []string{gitDir}
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		dirs = append(dirs, filepath.Clean(commonDir))
+	}
+	return dirs
+}
+
+// goModCache is where the go command keeps downloaded modules: GOMODCACHE,
+// then the first GOPATH entry, then ~/go.
+func goModCache(home string) string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+func testTimeout(cfg config.TestsConfig) (time.Duration, error) {
+	if cfg.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tests.timeout %q: %w", cfg.Timeout, err)
+	}
+	return timeout, nil
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianndofor/prq/internal/config"
+)
+
+func TestTestEnvStripsCredentials(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "HOME=/home/me", "GH_TOKEN=ghp_x", "AWS_SECRET_ACCESS_KEY=y", "EDITOR=vim"}
+	env := testEnv(environ, []string{"PATH", "HOME", "GH_TOKEN", "AWS_SECRET_ACCESS_KEY"})
+	if strings.Join(env, ",") != "PATH=/usr/bin,HOME=/home/me" {
+		t.Fatalf("unexpected env: %q", env)
+	}
+}
+
+func TestTestInvocation(t *testing.T) {
+	name, args, _, err := testInvocation(config.TestsConfig{Sandbox: "none"}, "/repo", "go test ./...", nil)
+	if err != nil || name != "sh" || strings.Join(args, " ") != "-c go test ./..." {
+		t.Fatalf("unexpected invocation: %s %q %v", name, args, err)
+	}
+	if _, _, _, err := testInvocation(config.TestsConfig{Sandbox: "docker"}, "/repo", "true", nil); err == nil {
+		t.Fatalf("expected an error for an unknown sandbox")
+	}
+}
+
+func TestBwrapArgsMasksCredentialsOnly(t *testing.T) {
+	home := t.TempDir()
+	modCache := filepath.Join(home, "go", "pkg", "mod")
+	gitDir := filepath.Join(home, ".prq", "mirrors", "acme", "app.git")
+	wtGitDir := filepath.Join(gitDir, "worktrees", "42")
+	repoDir := filepath.Join(home, ".prq", "mirrors", "worktrees", "acme", "app", "42")
+	for _, dir := range []string{modCache, wtGitDir, repoDir, filepath.Join(home, ".ssh"), filepath.Join(home, ".config", "gh")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(repoDir, ".git"):       "gitdir: " + wtGitDir + "\n",
+		filepath.Join(wtGitDir, "commondir"): "../..\n",
+		filepath.Join(home, ".netrc"):        "machine github.com password x\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOMODCACHE", "")
+	t.Setenv("GOPATH", "")
+
+	args := bwrapArgs(home, repoDir, "go test ./...")
+	mounts := map[string]string{}
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "--tmpfs":
+			mounts[args[i+1]] = "tmpfs"
+		case "--ro-bind", "--bind":
+			mounts[args[i+2]] = args[i] + " " + args[i+1]
+			i++
+		}
+	}
+	if mounts["/"] != "--ro-bind /" || mounts[home] != "" {
+		t.Fatalf("expected home to stay visible read-only, got %v", mounts)
+	}
+	for _, dir := range []string{filepath.Join(home, ".ssh"), filepath.Join(home, ".config", "gh")} {
+		if mounts[dir] != "tmpfs" {
+			t.Fatalf("expected %s to be masked, got %v", dir, mounts)
+		}
+	}
+	if mounts[filepath.Join(home, ".netrc")] != "--ro-bind "+os.DevNull {
+		t.Fatalf("expected ~/.netrc to be masked, got %v", mounts)
+	}
+	if _, ok := mounts[filepath.Join(home, ".aws")]; ok {
+		t.Fatalf("expected a missing credential dir not to be mounted, got %v", mounts)
+	}
+	for _, dir := range []string{wtGitDir, gitDir, modCache} {
+		if mounts[dir] != "--ro-bind "+dir {
+			t.Fatalf("expected %s to stay mounted read-only, got %v", dir, mounts)
+		}
+	}
+	if mounts[repoDir] != "--bind "+repoDir || !strings.HasSuffix(strings.Join(args, " "), "-- sh -c go test ./...") {
+		t.Fatalf("unexpected bwrap args: %q", args)
+	}
+}
+
+func TestRunLimitedTimeoutAndCap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	runner := RealExecRunner{}
+	res, err := runner.RunLimited(context.Background(), "", ExecOptions{Timeout: 100 * time.Millisecond}, "sh", "-c", "sleep 5")
+	if err != nil || !res.TimedOut {
+		t.Fatalf("expected a timeout, got %+v, %v", res, err)
+	}
+
+	res, err = runner.RunLimited(context.Background(), "", ExecOptions{MaxOutputBytes: 10}, "sh", "-c", "printf 0123456789abcdef; echo oops >&2; exit 3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ExitCode != 3 || strings.TrimSpace(res.Stderr) != "oops" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if !strings.HasPrefix(res.Stdout, "0123456789\n") || !strings.Contains(res.Stdout, "6 bytes dropped") {
+		t.Fatalf("unexpected stdout: %q", res.Stdout)
+	}
+
+	res, err = runner.RunLimited(context.Background(), "", ExecOptions{Env: []string{"ONLY=1"}}, "/bin/sh", "-c", "echo ${ONLY}${GH_TOKEN}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(res.Stdout) != "1" {
+		t.Fatalf("expected only the given env, got %q", res.Stdout)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/brianndofor/prq/internal/diff"
 	"github.com/brianndofor/prq/internal/github"
 	"github.com/brianndofor/prq/internal/provider"
+	"github.com/brianndofor/prq/internal/redact"
+	"github.com/brianndofor/prq/internal/store"
 	"github.com/spf13/cobra"
 )
 
@@ -24,6 +26,7 @@ func NewSubmitCmd() *cobra.Command {
 	var yes bool
 	var dryRun bool
 	var eventOverride string
+	var allowSensitive bool
 
 	cmd := &cobra.Command{
 		Use:   "submit <pr-url|OWNER/REPO#123>",
@@ -58,6 +61,9 @@ func NewSubmitCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if err := useRepoConfig(ctx, app, repo, view.BaseRefOid, cmd.ErrOrStderr()); err != nil {
+				return err
+			}
 			diffText, err := app.GH.PRDiff(ctx, fullRef)
 			if err != nil {
 				return err
@@ -85,16 +91,26 @@ func NewSubmitCmd() *cobra.Command {
 					event = "COMMENT"
 				}
 			}
-			issuePositions := mapIssuesToPositions(posMap, payload.Plan.Issues)
+			issues, warnings := omitPlaceholderPatches(payload.Plan.Issues)
+			issuePositions := mapIssuesToPositions(posMap, issues)
 			comments, unmapped := buildReviewComments(issuePositions)
 			body := buildReviewBody(payload.Plan.DraftReviewBody, payload.Plan.Summary, unmapped)
+			if found := redact.Placeholders(body); len(found) > 0 {
+				warnings = append(warnings, fmt.Sprintf("review body refers to redacted value(s) %s", strings.Join(found, ", ")))
+			}
 
-			preview := renderSubmitPreview(fullRef, payload, view.HeadRefOid, event, body, issuePositions, dryRun, eventErr)
+			req := github.CreateReviewRequest{Body: body, Event: event, Comments: comments}
+			hits := scanOutbound(app.Redactor, req)
+
+			preview := renderSubmitPreview(fullRef, payload, view.HeadRefOid, event, body, issuePositions, warnings, hits, dryRun, eventErr)
 			fmt.Fprint(cmd.OutOrStdout(), preview)
 
 			if dryRun {
 				return nil
 			}
+			if len(hits) > 0 && !allowSensitive {
+				return fmt.Errorf("review contains %d sensitive match(es); edit the draft or rerun with --allow-sensitive to post it anyway", len(hits))
+			}
 			if !yes {
 				ok, err := confirm(cmd, fmt.Sprintf("Post this review to %s? [y/N]: ", fullRef))
 				if err != nil {
@@ -106,17 +122,20 @@ func NewSubmitCmd() *cobra.Command {
 				}
 			}
 
-			resp, err := app.GH.CreateReview(ctx, repo, number, github.CreateReviewRequest{Body: body, Event: event, Comments: comments})
+			resp, err := app.GH.CreateReview(ctx, repo, number, req)
 			if err != nil {
 				return err
 			}
-			if err := app.Store.UpsertPR(fullRef, view.Repository.NameWithOwner, view.Number, view.HeadRefOid); err != nil {
-				return err
-			}
-			if err := app.Store.MarkSubmitted(fullRef); err != nil {
-				return err
-			}
-			if err := app.Store.DeleteDraftReview(fullRef); err != nil {
+			err = app.Store.InTx(func(tx *store.Store) error {
+				if err := tx.UpsertPR(fullRef, view.Repository.NameWithOwner, view.Number, view.HeadRefOid); err != nil {
+					return err
+				}
+				if err := tx.MarkSubmitted(fullRef); err != nil {
+					return err
+				}
+				return tx.DeleteDraftReview(fullRef)
+			})
+			if err != nil {
 				return err
 			}
 			if strings.TrimSpace(resp.HTMLURL) != "" {
@@ -133,6 +152,7 @@ func NewSubmitCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&yes, "yes", false, "Skip confirmation prompt")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview only; do not post")
 	cmd.Flags().StringVar(&eventOverride, "event", "", "Override review event (approve, comment, request_changes)")
+	cmd.Flags().BoolVar(&allowSensitive, "allow-sensitive", false, "Post even if the review matches a redaction rule or deny-list phrase")
 	return cmd
 }
 
@@ -208,7 +228,61 @@ func buildReviewBody(base string, summary string, unmapped []provider.Issue) str
 	return strings.TrimSpace(b.String())
 }
 
-func renderSubmitPreview(fullRef string, payload DraftReviewPayload, currentHeadSHA string, event string, body string, issuePositions []issuePosition, dryRun bool, decisionErr error) string {
+// omitPlaceholderPatches removes suggested patches containing a redaction
+// placeholder, which would otherwise be committed as-is if applied, and
+// warns about comments that still mention one.
+func omitPlaceholderPatches(issues []provider.Issue) ([]provider.Issue, []string) {
+	out := make([]provider.Issue, 0, len(issues))
+	var warnings []string
+	for _, issue := range issues {
+		if found := redact.Placeholders(issue.SuggestionPatch); len(found) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: suggested patch omitted; it contains %s", issueLocation(issue), strings.Join(found, ", ")))
+			issue.SuggestionPatch = ""
+		}
+		if found := redact.Placeholders(issue.Message); len(found) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: comment refers to redacted value(s) %s", issueLocation(issue), strings.Join(found, ", ")))
+		}
+		out = append(out, issue)
+	}
+	return out, warnings
+}
+
+// outboundHit is a sensitive match in the review about to be posted.
+type outboundHit struct {
+	// Where is "review body" or the comment's "path (position N)".
+	Where   string
+	Finding redact.Finding
+}
+
+// scanOutbound checks everything CreateReview would post for secrets and
+// deny-list phrases. Redaction only protects the prompt; this catches
+// content the model echoed from the PR or was never redacted.
+func scanOutbound(r *redact.Redactor, req github.CreateReviewRequest) []outboundHit {
+	var hits []outboundHit
+	for _, f := range r.Scan(req.Body) {
+		hits = append(hits, outboundHit{Where: "review body", Finding: f})
+	}
+	for _, c := range req.Comments {
+		where := fmt.Sprintf("comment on %s (position %d)", c.Path, c.Position)
+		for _, f := range r.Scan(c.Body) {
+			hits = append(hits, outboundHit{Where: where, Finding: f})
+		}
+	}
+	return hits
+}
+
+// String describes the hit without the matched secret; a deny-list phrase
+// is the user's own configuration, so it is named.
+func (h outboundHit) String() string {
+	f := h.Finding
+	what := f.Rule
+	if f.Rule == redact.DenyListRule {
+		what = fmt.Sprintf("%s %q", f.Rule, f.Phrase)
+	}
+	return fmt.Sprintf("%s, line %d: %s (%d chars, sha256 %s)", h.Where, f.Line, what, f.Length, f.Hash)
+}
+
+func renderSubmitPreview(fullRef string, payload DraftReviewPayload, currentHeadSHA string, event string, body string, issuePositions []issuePosition, warnings []string, hits []outboundHit, dryRun bool, decisionErr error) string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "PR: %s\n", fullRef)
 	if strings.TrimSpace(payload.HeadSHA) != "" {
@@ -223,6 +297,15 @@ func renderSubmitPreview(fullRef string, payload DraftReviewPayload, currentHead
 	if decisionErr != nil {
 		fmt.Fprintf(&b, "WARNING: unknown decision %q; defaulting to COMMENT.\n", payload.Plan.Decision)
 	}
+	for _, warning := range warnings {
+		fmt.Fprintf(&b, "WARNING: %s\n", warning)
+	}
+	for _, hit := range hits {
+		fmt.Fprintf(&b, "SENSITIVE: %s\n", hit)
+	}
+	if len(hits) > 0 {
+		b.WriteString("Posting requires --allow-sensitive.\n")
+	}
 	fmt.Fprintf(&b, "Event: %s\n", event)
 	if dryRun {
 		b.WriteString("DRY RUN: not posting to GitHub.\n")
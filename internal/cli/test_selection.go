@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/diff"
+)
+
+// packagesPlaceholder in a test command is replaced with the selected Go
+// packages.
+const packagesPlaceholder = "{PACKAGES}"
+
+// testSelection is the set of Go packages substituted for {PACKAGES}.
+type testSelection struct {
+	// All means every package (./...) is selected.
+	All      bool
+	Packages []string
+	// Reason explains the selection, e.g. why affected mode fell back to all.
+	Reason string
+}
+
+func (s testSelection) String() string {
+	switch {
+	case s.All && s.Reason != "":
+		return fmt.Sprintf("All packages (./...): %s", s.Reason)
+	case s.All:
+		return "All packages (./...)"
+	case len(s.Packages) == 0:
+		return fmt.Sprintf("No affected Go packages: %s", s.Reason)
+	default:
+		return fmt.Sprintf("Affected packages (%d): %s", len(s.Packages), strings.Join(s.Packages, " "))
+	}
+}
+
+// args is the {PACKAGES} substitution, or "" when nothing is selected.
+func (s testSelection) args() string {
+	if s.All {
+		return "./..."
+	}
+	return strings.Join(s.Packages, " ")
+}
+
+// goListPackage holds the fields of `go list -json` used for selection.
+type goListPackage struct {
+	ImportPath   string
+	Dir          string
+	DepOnly      bool
+	Standard     bool
+	Deps         []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// selectTestPackages applies tests.mode. In affected mode it lists the
+// module's packages with `go list -deps -json ./...` in repoDir and selects
+// the packages containing changed files plus every package that depends on
+// one of them, directly or through its tests. It falls back to all packages
+// when a changed Go file is in no listed package.
+func selectTestPackages(ctx context.Context, app *App, repoDir string, diffText string) testSelection {
+	if app.RepoConfig.Tests.Mode != "affected" {
+		return testSelection{All: true}
+	}
+	files, err := diff.ParseUnified(diffText)
+	if err != nil {
+		return testSelection{All: true, Reason: fmt.Sprintf("could not parse diff: %v", err)}
+	}
+	changedDirs := map[string]bool{}
+	goDirs := map[string]string{}
+	for _, file := range files {
+		if file.Path == "" {
+			continue
+		}
+		switch path.Base(file.Path) {
+		case "go.mod", "go.sum", "go.work", "go.work.sum":
+			return testSelection{All: true, Reason: file.Path + " changed"}
+		}
+		dir := filepath.Join(repoDir, filepath.FromSlash(packageDirOf(file.Path)))
+		changedDirs[dir] = true
+		if strings.HasSuffix(file.Path, ".go") && !strings.Contains(file.Text, "\n+++ /dev/null") {
+			goDirs[dir] = file.Path
+		}
+	}
+
+	// go list runs under the same sandbox and timeout as the test commands.
+	cfg := app.RepoConfig.Tests
+	timeout, err := testTimeout(cfg)
+	if err != nil {
+		return testSelection{All: true, Reason: err.Error()}
+	}
+	name, args, env, err := testInvocation(cfg, repoDir, "go list -deps -json ./...", testEnv(os.Environ(), cfg.EnvAllowlist))
+	if err != nil {
+		return testSelection{All: true, Reason: fmt.Sprintf("go list failed: %v", err)}
+	}
+	res, err := app.Exec.RunLimited(ctx, repoDir, ExecOptions{Env: env, Timeout: timeout}, name, args...)
+	if err == nil && res.TimedOut {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	if err == nil && res.ExitCode != 0 {
+		err = fmt.Errorf("exit %d: %s", res.ExitCode, strings.TrimSpace(res.Stderr))
+	}
+	if err != nil {
+		return testSelection{All: true, Reason: fmt.Sprintf("go list failed: %v", err)}
+	}
+	pkgs, err := decodeGoList([]byte(res.Stdout))
+	if err != nil {
+		return testSelection{All: true, Reason: err.Error()}
+	}
+	// A Go file outside every listed package, e.g. in a nested module, would
+	// otherwise select nothing and silently skip its tests.
+	listed := map[string]bool{}
+	for _, pkg := range pkgs {
+		if !pkg.DepOnly && !pkg.Standard {
+			listed[pkg.Dir] = true
+		}
+	}
+	var unlisted []string
+	for dir, file := range goDirs {
+		if !listed[dir] {
+			unlisted = append(unlisted, file)
+		}
+	}
+	if len(unlisted) > 0 {
+		sort.Strings(unlisted)
+		return testSelection{All: true, Reason: unlisted[0] + " is not in a package listed by go list"}
+	}
+	selected := affectedPackages(pkgs, changedDirs)
+	if len(selected) == 0 {
+		return testSelection{Reason: "the changed files are not in any Go package"}
+	}
+	return testSelection{Packages: selected}
+}
+
+// packageDirOf maps a changed file to the directory of the package it
+// belongs to; files under testdata belong to the enclosing package.
+func packageDirOf(file string) string {
+	dir := path.Dir(file)
+	parts := strings.Split(dir, "/")
+	for i, part := range parts {
+		if part == "testdata" {
+			if i == 0 {
+				return "."
+			}
+			return strings.Join(parts[:i], "/")
+		}
+	}
+	return dir
+}
+
+func decodeGoList(data []byte) ([]goListPackage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var pkgs []goListPackage
+	for {
+		var pkg goListPackage
+		err := dec.Decode(&pkg)
+		if err == io.EOF {
+			return pkgs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode go list output: %w", err)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+}
+
+// affectedPackages returns, sorted, the non-dependency packages in
+// changedDirs and those that import any of them.
+func affectedPackages(pkgs []goListPackage, changedDirs map[string]bool) []string {
+	changed := map[string]bool{}
+	for _, pkg := range pkgs {
+		if !pkg.Standard && changedDirs[pkg.Dir] {
+			changed[pkg.ImportPath] = true
+		}
+	}
+	var selected []string
+	for _, pkg := range pkgs {
+		if pkg.DepOnly || pkg.Standard {
+			continue
+		}
+		if changed[pkg.ImportPath] || anyIn(pkg.Deps, changed) || anyIn(pkg.TestImports, changed) || anyIn(pkg.XTestImports, changed) {
+			selected = append(selected, pkg.ImportPath)
+		}
+	}
+	sort.Strings(selected)
+	return selected
+}
+
+func anyIn(list []string, set map[string]bool) bool {
+	for _, item := range list {
+		if set[item] {
+			return true
+		}
+	}
+	return false
+}
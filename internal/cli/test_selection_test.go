@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianndofor/prq/internal/config"
+)
+
+// goListExecRunner answers `go list` with a canned package stream and
+// records the options it ran with.
+type goListExecRunner struct {
+	FakeExecRunner
+	stdout string
+	opts   *ExecOptions
+}
+
+func (r goListExecRunner) RunLimited(ctx context.Context, dir string, opts ExecOptions, name string, args ...string) (ExecResult, error) {
+	if name == "sh" && len(args) == 2 && strings.HasPrefix(args[1], "go list ") {
+		if r.opts != nil {
+			*r.opts = opts
+		}
+		return ExecResult{Stdout: r.stdout}, nil
+	}
+	return r.FakeExecRunner.RunLimited(ctx, dir, opts, name, args...)
+}
+
+func TestSelectTestPackagesAffected(t *testing.T) {
+	repoDir := t.TempDir()
+	dir := func(rel string) string { return filepath.Join(repoDir, rel) }
+	stdout := strings.Join([]string{
+		`{"ImportPath":"errors","Standard":true,"DepOnly":true}`,
+		`{"ImportPath":"example.com/app/auth","Dir":"` + dir("auth") + `","Deps":["errors"]}`,
+		`{"ImportPath":"example.com/app/api","Dir":"` + dir("api") + `","Deps":["errors","example.com/app/auth"]}`,
+		`{"ImportPath":"example.com/app/e2e","Dir":"` + dir("e2e") + `","XTestImports":["example.com/app/auth"]}`,
+		`{"ImportPath":"example.com/app/billing","Dir":"` + dir("billing") + `","Deps":["errors"]}`,
+	}, "\n")
+	var opts ExecOptions
+	app := &App{RepoConfig: config.DefaultRepoConfig(), Exec: goListExecRunner{stdout: stdout, opts: &opts}}
+	app.RepoConfig.Tests.Mode = "affected"
+	app.RepoConfig.Tests.Timeout = "2m"
+	diffText := "diff --git a/auth/testdata/golden.txt b/auth/testdata/golden.txt\n@@ -1 +1 @@\n-a\n+b\n"
+
+	sel := selectTestPackages(context.Background(), app, repoDir, diffText)
+	want := "example.com/app/api example.com/app/auth example.com/app/e2e"
+	if sel.All || sel.args() != want {
+		t.Fatalf("expected %q, got %+v", want, sel)
+	}
+	if sel.String() != "Affected packages (3): "+want {
+		t.Fatalf("unexpected report: %q", sel.String())
+	}
+	if opts.Timeout != 2*time.Minute {
+		t.Fatalf("expected go list to use tests.timeout, got %+v", opts)
+	}
+
+	sel = selectTestPackages(context.Background(), app, repoDir, "diff --git a/tools/gen/main.go b/tools/gen/main.go\n@@ -1 +1 @@\n-a\n+b\n")
+	if !sel.All || sel.String() != "All packages (./...): tools/gen/main.go is not in a package listed by go list" {
+		t.Fatalf("expected a file outside the listed packages to select everything, got %+v", sel)
+	}
+
+	sel = selectTestPackages(context.Background(), app, repoDir, "diff --git a/go.mod b/go.mod\n")
+	if !sel.All || sel.String() != "All packages (./...): go.mod changed" {
+		t.Fatalf("expected a go.mod change to select everything, got %+v", sel)
+	}
+
+	sel = selectTestPackages(context.Background(), app, repoDir, "diff --git a/docs/guide.md b/docs/guide.md\n")
+	if sel.All || sel.args() != "" {
+		t.Fatalf("expected no packages, got %+v", sel)
+	}
+	results, err := runTestCommands(context.Background(), app, repoDir, []string{"go test {PACKAGES}", "make lint"}, sel)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Status() != "SKIP" || results[1].Status() != "PASS" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestSelectTestPackagesAllMode(t *testing.T) {
+	app := &App{RepoConfig: config.DefaultRepoConfig(), Exec: FakeExecRunner{}}
+	sel := selectTestPackages(context.Background(), app, t.TempDir(), "")
+	if !sel.All || sel.args() != "./..." {
+		t.Fatalf("expected all packages, got %+v", sel)
+	}
+}
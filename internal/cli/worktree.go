@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultMirrorMaxAge = 7 * 24 * time.Hour
+
+// errLocked is returned by lockFile when another process holds the lock.
+var errLocked = errors.New("locked by another process")
+
+// prWorktree is a checkout of a PR head backed by a persistent bare mirror of
+// the repository. It holds the PR's lock until Release is called.
+type prWorktree struct {
+	Dir     string
+	GitDir  string
+	HeadSHA string
+
+	release func()
+}
+
+// Release unlocks the worktree so other runs on the same PR can reuse it.
+func (w *prWorktree) Release() {
+	if w.release != nil {
+		w.release()
+		w.release = nil
+	}
+}
+
+// mirrorRoot is where bare mirrors and worktrees live: PRQ_MIRROR_DIR, then
+// mirrors.dir, then ~/.prq/mirrors.
+func mirrorRoot(app *App) string {
+	if dir := os.Getenv("PRQ_MIRROR_DIR"); dir != "" {
+		return dir
+	}
+	if app.Config.Mirrors.Dir != "" {
+		return app.Config.Mirrors.Dir
+	}
+	return filepath.Join(os.Getenv("HOME"), ".prq", "mirrors")
+}
+
+func mirrorMaxAge(app *App) (time.Duration, error) {
+	if app.Config.Mirrors.MaxAge == "" {
+		return defaultMirrorMaxAge, nil
+	}
+	age, err := time.ParseDuration(app.Config.Mirrors.MaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mirrors.max_age %q: %w", app.Config.Mirrors.MaxAge, err)
+	}
+	return age, nil
+}
+
+// preparePRWorktree updates the bare mirror of repo (cloning it on first use),
+// fetches the PR head and base commits, and returns the PR's detached worktree
+// reset to the exact head SHA. There is one worktree per PR, locked so
+// concurrent runs on the same PR wait for each other; callers must Release it.
+// Stale worktrees are garbage collected first.
+func preparePRWorktree(ctx context.Context, app *App, repo string, number int, headSHA, baseSHA string) (wt prWorktree, err error) {
+	if repo == "" || headSHA == "" {
+		return prWorktree{}, fmt.Errorf("cannot check out %s#%d: missing repo or head SHA", repo, number)
+	}
+	root := mirrorRoot(app)
+	if maxAge, err := mirrorMaxAge(app); err == nil {
+		// Collection is best effort; a stale worktree must not block a review.
+		_, _ = gcWorktrees(ctx, app, root, maxAge, time.Now())
+	}
+
+	dir := filepath.Join(root, "worktrees", filepath.FromSlash(repo), strconv.Itoa(number))
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return prWorktree{}, fmt.Errorf("failed to create worktree dir: %w", err)
+	}
+	release, err := lockFile(dir+".lock", true)
+	if err != nil {
+		return prWorktree{}, err
+	}
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
+
+	gitDir := filepath.Join(root, filepath.FromSlash(repo)+".git")
+	if err := os.MkdirAll(filepath.Dir(gitDir), 0o755); err != nil {
+		return prWorktree{}, fmt.Errorf("failed to create mirror dir: %w", err)
+	}
+	// The mirror is shared by the repo's PRs, so creating, fetching into,
+	// and adding worktrees to it are serialized per repo.
+	releaseRepo, err := lockFile(gitDir+".lock", true)
+	if err != nil {
+		return prWorktree{}, err
+	}
+	defer releaseRepo()
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		if err := cloneMirror(ctx, app, repo, gitDir); err != nil {
+			return prWorktree{}, err
+		}
+	} else if err != nil {
+		return prWorktree{}, fmt.Errorf("failed to read mirror: %w", err)
+	}
+
+	fetchArgs := []string{"--git-dir", gitDir, "fetch", "--quiet", "origin", fmt.Sprintf("+refs/pull/%d/head:refs/prq/pull/%d", number, number)}
+	if baseSHA != "" {
+		fetchArgs = append(fetchArgs, baseSHA)
+	}
+	if _, err := app.Exec.Run(ctx, "", "git", fetchArgs...); err != nil {
+		return prWorktree{}, fmt.Errorf("failed to fetch PR: %w", err)
+	}
+
+	if _, err := os.Stat(dir); err == nil {
+		if _, err := app.Exec.Run(ctx, dir, "git", "reset", "--hard", "--quiet", headSHA); err != nil {
+			return prWorktree{}, fmt.Errorf("failed to reset worktree: %w", err)
+		}
+		if _, err := app.Exec.Run(ctx, dir, "git", "clean", "-ffdxq"); err != nil {
+			return prWorktree{}, fmt.Errorf("failed to clean worktree: %w", err)
+		}
+		now := time.Now()
+		_ = os.Chtimes(dir, now, now)
+	} else {
+		if _, err := app.Exec.Run(ctx, "", "git", "--git-dir", gitDir, "worktree", "add", "--detach", dir, headSHA); err != nil {
+			return prWorktree{}, fmt.Errorf("failed to create worktree: %w", err)
+		}
+	}
+	return prWorktree{Dir: dir, GitDir: gitDir, HeadSHA: headSHA, release: release}, nil
+}
+
+// cloneMirror clones repo as a bare mirror into a temporary directory next
+// to gitDir and renames it into place, so an interrupted clone never leaves
+// a partial mirror behind. The caller holds the repo lock.
+func cloneMirror(ctx context.Context, app *App, repo, gitDir string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(gitDir), filepath.Base(gitDir)+cloneSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to create mirror dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	target := filepath.Join(tmp, filepath.Base(gitDir))
+	if _, err := app.Exec.Run(ctx, "", "gh", "repo", "clone", repo, target, "--", "--bare"); err != nil {
+		return fmt.Errorf("failed to create mirror: %w", err)
+	}
+	if err := os.Rename(target, gitDir); err != nil {
+		return fmt.Errorf("failed to create mirror: %w", err)
+	}
+	return nil
+}
+
+// cloneSuffix marks the temporary directory of a mirror clone in progress,
+// e.g. app.git.clone-123.
+const cloneSuffix = ".clone-"
+
+// gcWorktrees removes worktrees under root not used since now-maxAge and
+// prunes their mirrors' worktree metadata. Worktrees locked by a running
+// review are skipped. It returns the removed paths.
+func gcWorktrees(ctx context.Context, app *App, root string, maxAge time.Duration, now time.Time) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(root, "worktrees", "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	var removed []string
+	pruned := map[string]bool{}
+	for _, dir := range matches {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() || now.Sub(info.ModTime()) < maxAge {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.Join(root, "worktrees"), dir)
+		if err != nil {
+			continue
+		}
+		release, err := lockFile(dir+".lock", false)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		gitDir := filepath.Join(root, parts[0], parts[1]+".git")
+		_, _ = app.Exec.Run(ctx, "", "git", "--git-dir", gitDir, "worktree", "remove", "--force", dir)
+		err = os.RemoveAll(dir)
+		if err == nil {
+			_ = os.Remove(dir + ".lock")
+		}
+		release()
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove worktree %s: %w", dir, err)
+		}
+		removed = append(removed, dir)
+		pruned[gitDir] = true
+	}
+	gitDirs := make([]string, 0, len(pruned))
+	for gitDir := range pruned {
+		gitDirs = append(gitDirs, gitDir)
+	}
+	sort.Strings(gitDirs)
+	for _, gitDir := range gitDirs {
+		if _, err := app.Exec.Run(ctx, "", "git", "--git-dir", gitDir, "worktree", "prune"); err != nil {
+			return removed, fmt.Errorf("failed to prune worktrees: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+// gcMirrors removes the bare mirrors under root of repos that are not in
+// tracked and have no worktrees left, along with their lock files and any
+// clone left behind by an interrupted run. Mirrors whose repo lock or PR
+// locks are held by a running review are skipped. It returns the removed
+// mirror paths.
+func gcMirrors(root string, tracked map[string]bool) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(root, "*", "*.git"))
+	if err != nil {
+		return nil, err
+	}
+	partial, err := filepath.Glob(filepath.Join(root, "*", "*.git"+cloneSuffix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	// A partial clone's repo has no mirror yet, so it is only reached here.
+	for _, tmp := range partial {
+		gitDir := tmp[:strings.LastIndex(tmp, cloneSuffix)]
+		if !slices.Contains(matches, gitDir) {
+			matches = append(matches, gitDir)
+		}
+	}
+	sort.Strings(matches)
+	var removed []string
+	for _, gitDir := range matches {
+		owner := filepath.Base(filepath.Dir(gitDir))
+		if owner == "worktrees" {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(gitDir), ".git")
+		repo := owner + "/" + name
+		if tracked[repo] {
+			continue
+		}
+		dirs, err := removeMirror(gitDir, filepath.Join(root, "worktrees", owner, name))
+		removed = append(removed, dirs...)
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// removeMirror deletes gitDir, its partial clones, and wtDir's lock files
+// if wtDir holds no worktrees and neither the repo lock nor any PR lock is
+// held. It returns the mirror and partial clone paths it removed.
+func removeMirror(gitDir, wtDir string) ([]string, error) {
+	entries, err := os.ReadDir(wtDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read worktrees of %s: %w", gitDir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return nil, nil
+		}
+	}
+	releaseRepo, err := lockFile(gitDir+".lock", false)
+	if errors.Is(err, errLocked) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer releaseRepo()
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".lock") {
+			continue
+		}
+		release, err := lockFile(filepath.Join(wtDir, entry.Name()), false)
+		if errors.Is(err, errLocked) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	dirs, err := filepath.Glob(gitDir + cloneSuffix + "*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(gitDir); err == nil {
+		dirs = append(dirs, gitDir)
+	}
+	var removed []string
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+		removed = append(removed, dir)
+	}
+	if err := os.RemoveAll(wtDir); err != nil {
+		return removed, fmt.Errorf("failed to remove %s: %w", wtDir, err)
+	}
+	_ = os.Remove(gitDir + ".lock")
+	return removed, nil
+}
+
+// gitSource reads files from a mirror's object store, so both the head and
+// base revisions are available without extra checkouts.
+type gitSource struct {
+	exec   ExecRunner
+	gitDir string
+}
+
+func (s gitSource) ReadFile(ctx context.Context, path, ref string) (string, error) {
+	return s.exec.Run(ctx, "", "git", "--git-dir", s.gitDir, "show", ref+":"+path)
+}
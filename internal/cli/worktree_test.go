@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingExecRunner records each command line and delegates to
+// FakeExecRunner.
+type recordingExecRunner struct {
+	calls *[]string
+}
+
+func (r recordingExecRunner) Run(ctx context.Context, dir string, name string, args ...string) (string, error) {
+	*r.calls = append(*r.calls, name+" "+strings.Join(args, " "))
+	return FakeExecRunner{}.Run(ctx, dir, name, args...)
+}
+
+func (r recordingExecRunner) RunLimited(ctx context.Context, dir string, opts ExecOptions, name string, args ...string) (ExecResult, error) {
+	*r.calls = append(*r.calls, name+" "+strings.Join(args, " "))
+	return FakeExecRunner{}.RunLimited(ctx, dir, opts, name, args...)
+}
+
+func TestPreparePRWorktreeReusesMirror(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("PRQ_MIRROR_DIR", root)
+	var calls []string
+	app := &App{Exec: recordingExecRunner{calls: &calls}}
+
+	wt, err := preparePRWorktree(context.Background(), app, "acme/app", 42, "head5678", "base1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wt.Release()
+	wantDir := filepath.Join(root, "worktrees", "acme", "app", "42")
+	if wt.Dir != wantDir || wt.GitDir != filepath.Join(root, "acme", "app.git") {
+		t.Fatalf("unexpected worktree: %+v", wt)
+	}
+	// The mirror is cloned next to its final path and renamed into place.
+	if info, err := os.Stat(wt.GitDir); err != nil || !info.IsDir() {
+		t.Fatalf("expected the mirror at %s: %v", wt.GitDir, err)
+	}
+	if leftovers, _ := filepath.Glob(wt.GitDir + cloneSuffix + "*"); len(leftovers) != 0 {
+		t.Fatalf("expected the temporary clone dir to be removed, got %q", leftovers)
+	}
+	if len(calls) != 3 || !strings.HasPrefix(calls[0], "gh repo clone acme/app") || !strings.Contains(calls[1], "+refs/pull/42/head:refs/prq/pull/42 base1234") || !strings.Contains(calls[2], "worktree add --detach "+wantDir+" head5678") {
+		t.Fatalf("unexpected commands: %q", calls)
+	}
+
+	// The mirror clone is faked as an empty dir, so a run for a new head must
+	// reuse both it and the PR's worktree.
+	calls = nil
+	wt, err = preparePRWorktree(context.Background(), app, "acme/app", 42, "head9999", "base1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wt.Release()
+	if wt.Dir != wantDir {
+		t.Fatalf("expected the PR worktree to be reused, got %s", wt.Dir)
+	}
+	for _, call := range calls {
+		if strings.Contains(call, "repo clone") || strings.Contains(call, "worktree add") {
+			t.Fatalf("expected the mirror and worktree to be reused, got %q", calls)
+		}
+	}
+	if len(calls) != 3 || calls[1] != "git reset --hard --quiet head9999" {
+		t.Fatalf("expected fetch, reset, and clean, got %q", calls)
+	}
+}
+
+// cloneCountingRunner counts mirror clones across goroutines.
+type cloneCountingRunner struct {
+	FakeExecRunner
+	mu     *sync.Mutex
+	clones *int
+}
+
+func (r cloneCountingRunner) Run(ctx context.Context, dir string, name string, args ...string) (string, error) {
+	if name == "gh" && len(args) > 1 && args[1] == "clone" {
+		r.mu.Lock()
+		*r.clones++
+		r.mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+	}
+	return r.FakeExecRunner.Run(ctx, dir, name, args...)
+}
+
+func TestPreparePRWorktreeClonesOncePerRepo(t *testing.T) {
+	t.Setenv("PRQ_MIRROR_DIR", t.TempDir())
+	var clones int
+	app := &App{Exec: cloneCountingRunner{mu: &sync.Mutex{}, clones: &clones}}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wt, err := preparePRWorktree(context.Background(), app, "acme/app", i+1, "head5678", "")
+			wt.Release()
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if clones != 1 {
+		t.Fatalf("expected concurrent runs on one repo to clone its mirror once, got %d clones", clones)
+	}
+}
+
+func TestGCWorktrees(t *testing.T) {
+	root := t.TempDir()
+	stale := filepath.Join(root, "worktrees", "acme", "app", "7")
+	busy := filepath.Join(root, "worktrees", "acme", "app", "9")
+	fresh := filepath.Join(root, "worktrees", "acme", "app", "42")
+	for _, dir := range []string{stale, busy, fresh} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	now := time.Now()
+	old := now.Add(-10 * 24 * time.Hour)
+	for _, dir := range []string{stale, busy} {
+		if err := os.Chtimes(dir, old, old); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	release, err := lockFile(busy+".lock", true)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer release()
+
+	app := &App{Exec: FakeExecRunner{}}
+	removed, err := gcWorktrees(context.Background(), app, root, 7*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != stale {
+		t.Fatalf("unexpected removals: %q", removed)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale worktree to be removed")
+	}
+	if _, err := os.Stat(stale + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale worktree's lock file to be removed")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("expected fresh worktree to remain: %v", err)
+	}
+}
+
+func TestGCMirrors(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(root, "acme", "app.git"),
+		filepath.Join(root, "acme", "lib.git"),
+		filepath.Join(root, "acme", "old.git"),
+		filepath.Join(root, "acme", "cloning.git"),
+		filepath.Join(root, "acme", "fetching.git"),
+		filepath.Join(root, "acme", "partial.git.clone-123", "partial.git"),
+		filepath.Join(root, "worktrees", "acme", "lib", "3"),
+		filepath.Join(root, "worktrees", "acme", "old"),
+		filepath.Join(root, "worktrees", "acme", "fetching"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "worktrees", "acme", "old", "5.lock"), nil, 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+	// A run holding the repo lock, and one holding only its PR lock while it
+	// waits to fetch, keep their mirrors.
+	for _, path := range []string{
+		filepath.Join(root, "acme", "cloning.git.lock"),
+		filepath.Join(root, "worktrees", "acme", "fetching", "8.lock"),
+	} {
+		release, err := lockFile(path, true)
+		if err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		defer release()
+	}
+
+	removed, err := gcMirrors(root, map[string]bool{"acme/app": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	old := filepath.Join(root, "acme", "old.git")
+	partial := filepath.Join(root, "acme", "partial.git.clone-123")
+	if strings.Join(removed, ",") != old+","+partial {
+		t.Fatalf("unexpected removals: %q", removed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "worktrees", "acme", "old")); !os.IsNotExist(err) {
+		t.Fatalf("expected the untracked repo's worktree dir to be removed")
+	}
+	for _, dir := range []string{"app.git", "lib.git", "cloning.git", "fetching.git"} {
+		if _, err := os.Stat(filepath.Join(root, "acme", dir)); err != nil {
+			t.Fatalf("expected %s to remain: %v", dir, err)
+		}
+	}
+}
@@ -1,19 +1,29 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Provider  ProviderConfig  `mapstructure:"provider"`
-	UserRules []string        `mapstructure:"user_rules"`
-	Queue     QueueConfig     `mapstructure:"queue"`
-	Redaction RedactionConfig `mapstructure:"redaction"`
-	TUI       TUIConfig       `mapstructure:"tui"`
+	Provider  ProviderConfig           `mapstructure:"provider"`
+	UserRules []string                 `mapstructure:"user_rules"`
+	Queue     QueueConfig              `mapstructure:"queue"`
+	Redaction RedactionConfig          `mapstructure:"redaction"`
+	TUI       TUIConfig                `mapstructure:"tui"`
+	Mirrors   MirrorsConfig            `mapstructure:"mirrors"`
+	Profiles  map[string]ProfileConfig `mapstructure:"profiles"`
 }
 
 type ProviderConfig struct {
@@ -22,26 +32,142 @@ type ProviderConfig struct {
 }
 
 type QueueConfig struct {
-	DefaultLimit int    `mapstructure:"default_limit"`
-	DefaultSort  string `mapstructure:"default_sort"`
+	DefaultLimit int `mapstructure:"default_limit"`
+	// DefaultSort is empty unless set; each command then uses its own
+	// default (queue: oldest, pick: status).
+	DefaultSort string `mapstructure:"default_sort"`
 }
 
 type RedactionConfig struct {
 	Enabled bool `mapstructure:"enabled"`
+	// Rules are named regexes redacted in addition to the built-in rules.
+	Rules []RedactionRule `mapstructure:"rules"`
+	// Allowlist holds regexes for known-safe strings that are never redacted.
+	Allowlist []string `mapstructure:"allowlist"`
+	// DenyList holds phrases, such as internal codenames, that must not
+	// appear in a posted review.
+	DenyList []string `mapstructure:"deny_list"`
+}
+
+// RepoRedactionConfig adds repo-specific rules, allowlist patterns, and
+// deny-list phrases to the user's; a repo cannot turn redaction off.
+type RepoRedactionConfig struct {
+	Rules     []RedactionRule `mapstructure:"rules"`
+	Allowlist []string        `mapstructure:"allowlist"`
+	DenyList  []string        `mapstructure:"deny_list"`
+}
+
+type RedactionRule struct {
+	Name    string `mapstructure:"name"`
+	Pattern string `mapstructure:"pattern"`
+}
+
+// ProfileConfig is a focused review pass, such as security or docs, selected
+// with --profile or by repo auto_profiles.
+type ProfileConfig struct {
+	// Prompt and Schema are paths, relative to the repo root, of files that
+	// replace the default prompt template and review schema. They are read
+	// from the reviewed repo at the PR's base commit.
+	Prompt string `mapstructure:"prompt"`
+	Schema string `mapstructure:"schema"`
+	// Rules are added to the prompt as the profile's focus.
+	Rules []string `mapstructure:"rules"`
+	// Categories keeps only issues in these categories; empty keeps all.
+	Categories []string `mapstructure:"categories"`
+	// ProviderArgs are appended to provider.args. Only the user config may
+	// set them.
+	ProviderArgs []string `mapstructure:"provider_args"`
+}
+
+// ProfileSelector picks a profile when a changed file matches one of Paths.
+type ProfileSelector struct {
+	Profile string `mapstructure:"profile"`
+	// Paths are globs where "**" matches any number of directories.
+	Paths []string `mapstructure:"paths"`
 }
 
 type TUIConfig struct {
 	Enabled bool `mapstructure:"enabled"`
 }
 
+// MirrorsConfig controls the persistent bare mirrors and PR worktrees used for
+// --run-tests and local context expansion.
+type MirrorsConfig struct {
+	// Dir defaults to ~/.prq/mirrors.
+	Dir string `mapstructure:"dir"`
+	// MaxAge is how long an unused worktree is kept, as a Go duration.
+	MaxAge string `mapstructure:"max_age"`
+}
+
 type RepoConfig struct {
-	RepoRules []string    `mapstructure:"repo_rules"`
-	Tests     TestsConfig `mapstructure:"tests"`
-	Diff      DiffConfig  `mapstructure:"diff"`
+	RepoRules []RepoRule `mapstructure:"repo_rules"`
+	// Guidelines are Markdown files whose list items are added as rules,
+	// read from the reviewed repo at the PR's base commit. When empty, the
+	// first REVIEW_GUIDELINES.md found in .github/, the repo root, or docs/
+	// (where CODEOWNERS is looked up) is read.
+	Guidelines []GuidelineFile     `mapstructure:"guidelines"`
+	Tests      TestsConfig         `mapstructure:"tests"`
+	Analyzers  []AnalyzerConfig    `mapstructure:"analyzers"`
+	Diff       DiffConfig          `mapstructure:"diff"`
+	Context    ContextConfig       `mapstructure:"context"`
+	Redaction  RepoRedactionConfig `mapstructure:"redaction"`
+	// Profiles are added to the user's; a repo profile replaces a user
+	// profile of the same name.
+	Profiles map[string]ProfileConfig `mapstructure:"profiles"`
+	// AutoProfiles select a profile by changed path when --profile is not
+	// given; the first match wins.
+	AutoProfiles []ProfileSelector `mapstructure:"auto_profiles"`
+}
+
+// RepoRule is a review rule for the repo. A rule with Paths is only used
+// when the PR changes a file matching one of them. In prq.yaml an entry may
+// also be a plain string.
+type RepoRule struct {
+	Rule  string   `mapstructure:"rule"`
+	Paths []string `mapstructure:"paths"`
+}
+
+// GuidelineFile is a Markdown file of review rules, relative to the repo
+// root. Paths scopes every rule in the file, as for RepoRule. In prq.yaml
+// an entry may also be a plain path.
+type GuidelineFile struct {
+	Path  string   `mapstructure:"path"`
+	Paths []string `mapstructure:"paths"`
 }
 
 type TestsConfig struct {
 	Commands []string `mapstructure:"commands"`
+	// Timeout bounds each command, as a Go duration.
+	Timeout        string `mapstructure:"timeout"`
+	MaxOutputBytes int    `mapstructure:"max_output_bytes"`
+	// EnvAllowlist names the environment variables passed to commands.
+	// Names that look like credentials are dropped even when listed.
+	EnvAllowlist []string `mapstructure:"env_allowlist"`
+	// Sandbox is "none" or "bwrap" (bubblewrap: no network, read-only
+	// filesystem except the worktree and a private /tmp).
+	Sandbox string `mapstructure:"sandbox"`
+	// JUnitPaths are globs, relative to the checkout, of JUnit XML reports
+	// written by the commands.
+	JUnitPaths []string `mapstructure:"junit_paths"`
+	// Mode is "all" or "affected": substitute only the Go packages touched
+	// by the PR, and their reverse dependencies, for {PACKAGES}.
+	Mode string `mapstructure:"mode"`
+}
+
+// DefaultTestEnvAllowlist is the environment passed to test commands when
+// tests.env_allowlist is not set.
+func DefaultTestEnvAllowlist() []string {
+	return []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TERM", "TMPDIR", "GOPATH", "GOCACHE", "GOMODCACHE", "GOFLAGS", "GOPROXY", "GOTOOLCHAIN"}
+}
+
+// AnalyzerConfig is a static analyzer run in the PR worktree alongside the
+// test commands, with the same environment, sandbox, and limits.
+type AnalyzerConfig struct {
+	// Name tags the findings; it defaults to the command's first word.
+	Name    string `mapstructure:"name"`
+	Command string `mapstructure:"command"`
+	// Format is "text" (path:line[:col]: message) or "golangci-json".
+	Format string `mapstructure:"format"`
 }
 
 type DiffConfig struct {
@@ -50,6 +176,26 @@ type DiffConfig struct {
 	MaxChunkChars int      `mapstructure:"max_chunk_chars"`
 }
 
+// ContextConfig controls expanding diff hunks with surrounding file contents.
+type ContextConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Lines is how many lines to add above and below each hunk.
+	Lines int `mapstructure:"lines"`
+	// Enclosing widens a hunk to its enclosing top-level block when one is found.
+	Enclosing bool `mapstructure:"enclosing"`
+	// Source is where file contents are read from: "api" (GitHub contents
+	// API) or "local" (the PR worktree's mirror).
+	Source string `mapstructure:"source"`
+	// Related adds definitions and references of changed exported Go
+	// identifiers when a local checkout exists (--run-tests).
+	Related       bool `mapstructure:"related"`
+	MaxReferences int  `mapstructure:"max_references"`
+	// MaxChars caps the whole context section; files past it are left out.
+	MaxChars int `mapstructure:"max_chars"`
+	// MaxFileBytes skips files larger than this instead of excerpting them.
+	MaxFileBytes int `mapstructure:"max_file_bytes"`
+}
+
 func Defaults() Config {
 	return Config{
 		Provider: ProviderConfig{
@@ -58,53 +204,325 @@ func Defaults() Config {
 		},
 		Queue: QueueConfig{
 			DefaultLimit: 200,
-			DefaultSort:  "oldest",
 		},
 		Redaction: RedactionConfig{Enabled: true},
 		TUI:       TUIConfig{Enabled: true},
+		Mirrors:   MirrorsConfig{MaxAge: "168h"},
 	}
 }
 
 func DefaultRepoConfig() RepoConfig {
 	return RepoConfig{
-		RepoRules: []string{},
-		Tests:     TestsConfig{Commands: []string{}},
+		RepoRules: []RepoRule{},
+		Tests: TestsConfig{
+			Commands:       []string{},
+			Timeout:        "10m",
+			MaxOutputBytes: 65536,
+			EnvAllowlist:   DefaultTestEnvAllowlist(),
+			Sandbox:        "none",
+			Mode:           "all",
+		},
+		Analyzers: []AnalyzerConfig{},
 		Diff: DiffConfig{
 			Ignore:        []string{},
 			MaxFiles:      50,
 			MaxChunkChars: 8000,
 		},
+		Context: ContextConfig{
+			Enabled:       false,
+			Lines:         20,
+			Enclosing:     true,
+			Source:        "api",
+			Related:       true,
+			MaxReferences: 10,
+			MaxChars:      60000,
+			MaxFileBytes:  262144,
+		},
 	}
 }
 
+// Load reads the user config and the repo config in the working directory.
 func Load(configPath string) (Config, RepoConfig, error) {
 	userCfg := Defaults()
-	repoCfg := DefaultRepoConfig()
-
 	if err := loadUserConfig(configPath, &userCfg); err != nil {
 		return Config{}, RepoConfig{}, err
 	}
-	if err := loadRepoConfig(&repoCfg); err != nil {
+	finishUserConfig(&userCfg)
+	var layers []RepoSource
+	local, err := LocalRepoSource()
+	if err != nil {
 		return Config{}, RepoConfig{}, err
 	}
+	if local != nil {
+		layers = append(layers, *local)
+	}
+	repoCfg, err := LoadRepo(userCfg, layers)
+	if err != nil {
+		return Config{}, RepoConfig{}, err
+	}
+	return userCfg, repoCfg, nil
+}
+
+// RepoSource is one layer of repo config and where it came from.
+type RepoSource struct {
+	// Origin describes the layer, e.g. "acme/app@1a2b3c:prq.yaml" or
+	// "./prq.yaml".
+	Origin  string
+	Content []byte
+	// Remote marks a layer committed to the reviewed repo, of which only
+	// the keys safeRemoteSettings keeps are used.
+	Remote bool
+}
+
+// settings returns the layer's keys, filtered for a remote layer, and the
+// dotted names of the keys that were dropped.
+func (s RepoSource) settings() (map[string]any, []string, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(s.Content)); err != nil {
+		return nil, nil, fmt.Errorf("failed to load repo config %s: %w", s.Origin, err)
+	}
+	if !s.Remote {
+		return v.AllSettings(), nil, nil
+	}
+	kept, ignored := safeRemoteSettings(v.AllSettings())
+	return kept, ignored, nil
+}
+
+// IgnoredKeys lists the keys of a remote layer that were not applied.
+func (s RepoSource) IgnoredKeys() ([]string, error) {
+	_, ignored, err := s.settings()
+	return ignored, err
+}
+
+// safeRemoteSettings keeps the settings a repo's committed config may
+// apply: rules, repo-relative guidelines, diff and context limits, added
+// redaction rules and deny-list phrases, and profiles' rules and
+// categories. Anything that runs commands, reads local files, passes
+// provider arguments, or weakens redaction must come from the user config
+// or ./prq.yaml.
+func safeRemoteSettings(settings map[string]any) (map[string]any, []string) {
+	kept := map[string]any{}
+	var ignored []string
+	for key, value := range settings {
+		switch key {
+		case "repo_rules", "guidelines", "diff", "context":
+			kept[key] = value
+		case "redaction":
+			kept[key], ignored = keepSubkeys(key, value, ignored, "rules", "deny_list")
+		case "profiles":
+			profiles, ok := value.(map[string]any)
+			if !ok {
+				ignored = append(ignored, key)
+				continue
+			}
+			safe := map[string]any{}
+			for name, profile := range profiles {
+				safe[name], ignored = keepSubkeys(key+"."+name, profile, ignored, "rules", "categories")
+			}
+			kept[key] = safe
+		default:
+			ignored = append(ignored, key)
+		}
+	}
+	sort.Strings(ignored)
+	return kept, ignored
+}
+
+// keepSubkeys returns the allowed keys of the map value, appending the
+// others, named under prefix, to ignored.
+func keepSubkeys(prefix string, value any, ignored []string, allowed ...string) (map[string]any, []string) {
+	kept := map[string]any{}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return kept, append(ignored, prefix)
+	}
+	for key, v := range m {
+		if slices.Contains(allowed, key) {
+			kept[key] = v
+		} else {
+			ignored = append(ignored, prefix+"."+key)
+		}
+	}
+	return kept, ignored
+}
+
+// LocalRepoSource reads ./prq.yaml, returning nil if there is none.
+func LocalRepoSource() (*RepoSource, error) {
+	path := filepath.Join(".", "prq.yaml")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read repo config: %w", err)
+	}
+	return &RepoSource{Origin: "./prq.yaml", Content: content}, nil
+}
+
+// LoadRepo merges the repo config layers, each overriding the keys of the
+// ones before it, over the defaults and validates the result. Remote layers
+// only contribute their safe keys. Nested
+// settings merge key by key; lists are replaced.
+func LoadRepo(userCfg Config, layers []RepoSource) (RepoConfig, error) {
+	repoCfg := DefaultRepoConfig()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	for _, layer := range layers {
+		settings, _, err := layer.settings()
+		if err != nil {
+			return RepoConfig{}, err
+		}
+		if err := v.MergeConfigMap(settings); err != nil {
+			return RepoConfig{}, fmt.Errorf("failed to load repo config %s: %w", layer.Origin, err)
+		}
+	}
+	if err := v.Unmarshal(&repoCfg, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringEntryHook,
+	))); err != nil {
+		return RepoConfig{}, fmt.Errorf("failed to parse repo config: %w", err)
+	}
+	if err := finishRepoConfig(userCfg, &repoCfg); err != nil {
+		return RepoConfig{}, err
+	}
+	return repoCfg, nil
+}
+
+// Provenance maps each top-level repo config key to the origin of the last
+// layer that sets it. Keys no layer sets keep their defaults.
+func Provenance(layers []RepoSource) (map[string]string, error) {
+	origins := map[string]string{}
+	for _, layer := range layers {
+		settings, _, err := layer.settings()
+		if err != nil {
+			return nil, err
+		}
+		for key := range settings {
+			origins[key] = layer.Origin
+		}
+	}
+	return origins, nil
+}
 
+func finishUserConfig(userCfg *Config) {
 	if userCfg.Provider.Command == "" {
 		userCfg.Provider.Command = "claude"
 	}
 	if userCfg.Queue.DefaultLimit == 0 {
 		userCfg.Queue.DefaultLimit = 200
 	}
-	if userCfg.Queue.DefaultSort == "" {
-		userCfg.Queue.DefaultSort = "oldest"
-	}
+}
+
+// finishRepoConfig fills in repo defaults and validates the merged config.
+func finishRepoConfig(userCfg Config, repoCfg *RepoConfig) error {
 	if repoCfg.Diff.MaxFiles == 0 {
 		repoCfg.Diff.MaxFiles = 50
 	}
 	if repoCfg.Diff.MaxChunkChars == 0 {
 		repoCfg.Diff.MaxChunkChars = 8000
 	}
+	if repoCfg.Context.Lines == 0 {
+		repoCfg.Context.Lines = 20
+	}
+	if repoCfg.Tests.Timeout == "" {
+		repoCfg.Tests.Timeout = "10m"
+	}
+	if _, err := time.ParseDuration(repoCfg.Tests.Timeout); err != nil {
+		return fmt.Errorf("invalid tests.timeout %q: %w", repoCfg.Tests.Timeout, err)
+	}
+	if repoCfg.Tests.MaxOutputBytes == 0 {
+		repoCfg.Tests.MaxOutputBytes = 65536
+	}
+	if len(repoCfg.Tests.EnvAllowlist) == 0 {
+		repoCfg.Tests.EnvAllowlist = DefaultTestEnvAllowlist()
+	}
+	if repoCfg.Tests.Sandbox == "" {
+		repoCfg.Tests.Sandbox = "none"
+	}
+	if repoCfg.Tests.Sandbox != "none" && repoCfg.Tests.Sandbox != "bwrap" {
+		return fmt.Errorf("invalid tests.sandbox %q: expected none or bwrap", repoCfg.Tests.Sandbox)
+	}
+	if repoCfg.Tests.Mode == "" {
+		repoCfg.Tests.Mode = "all"
+	}
+	if repoCfg.Tests.Mode != "all" && repoCfg.Tests.Mode != "affected" {
+		return fmt.Errorf("invalid tests.mode %q: expected all or affected", repoCfg.Tests.Mode)
+	}
+	for i := range repoCfg.Analyzers {
+		analyzer := &repoCfg.Analyzers[i]
+		fields := strings.Fields(analyzer.Command)
+		if len(fields) == 0 {
+			return fmt.Errorf("analyzers[%d]: command is required", i)
+		}
+		if analyzer.Name == "" {
+			analyzer.Name = fields[0]
+		}
+		if analyzer.Format == "" {
+			analyzer.Format = "text"
+		}
+		if analyzer.Format != "text" && analyzer.Format != "golangci-json" {
+			return fmt.Errorf("invalid analyzers[%d].format %q: expected text or golangci-json", i, analyzer.Format)
+		}
+	}
+	if repoCfg.Context.Source == "" {
+		repoCfg.Context.Source = "api"
+	}
+	if repoCfg.Context.Source != "api" && repoCfg.Context.Source != "local" {
+		return fmt.Errorf("invalid context.source %q: expected api or local", repoCfg.Context.Source)
+	}
+	if repoCfg.Context.MaxReferences == 0 {
+		repoCfg.Context.MaxReferences = 10
+	}
+	if repoCfg.Context.MaxChars == 0 {
+		repoCfg.Context.MaxChars = 60000
+	}
+	if repoCfg.Context.MaxFileBytes == 0 {
+		repoCfg.Context.MaxFileBytes = 262144
+	}
+	for i, rule := range repoCfg.RepoRules {
+		if strings.TrimSpace(rule.Rule) == "" {
+			return fmt.Errorf("repo_rules[%d]: rule is required", i)
+		}
+	}
+	for i, file := range repoCfg.Guidelines {
+		if strings.TrimSpace(file.Path) == "" {
+			return fmt.Errorf("guidelines[%d]: path is required", i)
+		}
+		if _, err := RepoPath(file.Path); err != nil {
+			return fmt.Errorf("guidelines[%d]: %w", i, err)
+		}
+	}
+	for name, profile := range repoCfg.Profiles {
+		if len(profile.ProviderArgs) > 0 {
+			return fmt.Errorf("profiles.%s.provider_args can only be set in the user config", name)
+		}
+	}
+	profiles := MergeProfiles(userCfg, *repoCfg)
+	for name, profile := range profiles {
+		for key, file := range map[string]string{"prompt": profile.Prompt, "schema": profile.Schema} {
+			if file == "" {
+				continue
+			}
+			if _, err := RepoPath(file); err != nil {
+				return fmt.Errorf("profiles.%s.%s: %w", name, key, err)
+			}
+		}
+	}
+	for i := range repoCfg.AutoProfiles {
+		selector := &repoCfg.AutoProfiles[i]
+		// Viper lowercases map keys, so profile names are case-insensitive.
+		selector.Profile = strings.ToLower(selector.Profile)
+		if _, ok := profiles[selector.Profile]; !ok {
+			return fmt.Errorf("auto_profiles[%d]: unknown profile %q", i, selector.Profile)
+		}
+		if len(selector.Paths) == 0 {
+			return fmt.Errorf("auto_profiles[%d]: paths are required", i)
+		}
+	}
 
-	return userCfg, repoCfg, nil
+	return nil
 }
 
 func loadUserConfig(configPath string, cfg *Config) error {
@@ -130,22 +548,50 @@ func loadUserConfig(configPath string, cfg *Config) error {
 	return nil
 }
 
-func loadRepoConfig(cfg *RepoConfig) error {
-	path := filepath.Join(".", "prq.yaml")
-	if _, err := os.Stat(path); err != nil {
-		if os.IsNotExist(err) {
-			return nil
+// MergeProfiles returns the user's profiles with the repo's added, a repo
+// profile replacing a user profile of the same name except for its
+// provider_args, which only the user config sets.
+func MergeProfiles(cfg Config, repoCfg RepoConfig) map[string]ProfileConfig {
+	profiles := map[string]ProfileConfig{}
+	for name, profile := range cfg.Profiles {
+		profiles[name] = profile
+	}
+	for name, profile := range repoCfg.Profiles {
+		profile.ProviderArgs = cfg.Profiles[name].ProviderArgs
+		profiles[name] = profile
+	}
+	return profiles
+}
+
+// RepoPath checks that p is a path relative to the repo root that stays
+// inside the repo, and returns it slash-separated and cleaned.
+func RepoPath(p string) (string, error) {
+	slashed := filepath.ToSlash(strings.TrimSpace(p))
+	if slashed == "" {
+		return "", fmt.Errorf("path is empty")
+	}
+	if path.IsAbs(slashed) || filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return "", fmt.Errorf("path %q must be relative to the repo root", p)
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("path %q must not contain ..", p)
 		}
-		return fmt.Errorf("failed to read repo config: %w", err)
 	}
+	return path.Clean(slashed), nil
+}
 
-	v := viper.New()
-	v.SetConfigFile(path)
-	if err := v.ReadInConfig(); err != nil {
-		return fmt.Errorf("failed to load repo config: %w", err)
+// stringEntryHook decodes a plain string in repo_rules or guidelines as a
+// rule or a path.
+func stringEntryHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String {
+		return data, nil
 	}
-	if err := v.Unmarshal(cfg); err != nil {
-		return fmt.Errorf("failed to parse repo config: %w", err)
+	switch to {
+	case reflect.TypeOf(RepoRule{}):
+		return map[string]any{"rule": data}, nil
+	case reflect.TypeOf(GuidelineFile{}):
+		return map[string]any{"path": data}, nil
 	}
-	return nil
+	return data, nil
 }
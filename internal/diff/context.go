@@ -0,0 +1,238 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxEnclosingLines bounds how far an enclosing block may extend before the
+// expansion falls back to a fixed number of lines.
+const maxEnclosingLines = 200
+
+// LineRange is an inclusive, 1-based range of lines in a file.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// FileStatus reports whether the diff adds, deletes, or modifies the file.
+func (f FileDiff) FileStatus() string {
+	switch {
+	case strings.Contains(f.Text, "\nnew file mode"), strings.Contains(f.Text, "\n--- /dev/null"):
+		return "added"
+	case strings.Contains(f.Text, "\ndeleted file mode"), strings.Contains(f.Text, "\n+++ /dev/null"):
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// IsBinary reports whether git diffed the file as binary, so it has no text
+// to excerpt.
+func (f FileDiff) IsBinary() bool {
+	return strings.Contains(f.Text, "\nBinary files ") || strings.Contains(f.Text, "\nGIT binary patch")
+}
+
+// OldPath returns the pre-image path of a file diff, which differs from Path
+// for renames.
+func (f FileDiff) OldPath() string {
+	for _, line := range strings.Split(f.Text, "\n") {
+		if strings.HasPrefix(line, "--- a/") {
+			return strings.TrimPrefix(line, "--- a/")
+		}
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+	}
+	return f.Path
+}
+
+// ChangedLines returns the new-side lines a file diff adds, plus, for each
+// run of removed lines, the line now at the point of removal. Unlike
+// HunkRanges it leaves out the context lines around each change.
+func ChangedLines(f FileDiff) []LineRange {
+	var lines []int
+	newLine := 0
+	inPatch := false
+	for _, line := range strings.Split(f.Text, "\n") {
+		if matches := hunkHeaderRE.FindStringSubmatch(line); len(matches) > 0 {
+			newLine, _ = strconv.Atoi(matches[3])
+			inPatch = true
+			continue
+		}
+		if !inPatch || line == "" {
+			continue
+		}
+		switch line[0] {
+		case ' ':
+			newLine++
+		case '+':
+			lines = append(lines, newLine)
+			newLine++
+		case '-':
+			lines = append(lines, newLine)
+		}
+	}
+	var ranges []LineRange
+	for _, line := range lines {
+		if n := len(ranges); n > 0 && line <= ranges[n-1].End+1 {
+			ranges[n-1].End = max(ranges[n-1].End, line)
+			continue
+		}
+		ranges = append(ranges, LineRange{Start: line, End: line})
+	}
+	return ranges
+}
+
+// HunkRanges returns the old-side and new-side line ranges touched by each
+// hunk of a file diff.
+func HunkRanges(f FileDiff) (oldRanges []LineRange, newRanges []LineRange) {
+	for _, line := range strings.Split(f.Text, "\n") {
+		matches := hunkHeaderRE.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		if r, ok := hunkRange(matches[1], matches[2]); ok {
+			oldRanges = append(oldRanges, r)
+		}
+		if r, ok := hunkRange(matches[3], matches[4]); ok {
+			newRanges = append(newRanges, r)
+		}
+	}
+	return oldRanges, newRanges
+}
+
+func hunkRange(startText string, countText string) (LineRange, bool) {
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return LineRange{}, false
+	}
+	count := 1
+	if countText != "" {
+		count, err = strconv.Atoi(countText)
+		if err != nil {
+			return LineRange{}, false
+		}
+	}
+	if count == 0 {
+		return LineRange{}, false
+	}
+	return LineRange{Start: start, End: start + count - 1}, true
+}
+
+// ExpandRanges widens each range by extra lines on both sides, or to its
+// enclosing top-level block when enclosing is set and one is found, and
+// merges ranges that touch.
+func ExpandRanges(content string, ranges []LineRange, extra int, enclosing bool) []LineRange {
+	lines := strings.Split(content, "\n")
+	total := len(lines)
+	var expanded []LineRange
+	for _, r := range ranges {
+		out := LineRange{Start: r.Start - extra, End: r.End + extra}
+		if enclosing {
+			if block, ok := enclosingBlock(lines, r); ok {
+				out = block
+			}
+		}
+		if out.Start < 1 {
+			out.Start = 1
+		}
+		if out.End > total {
+			out.End = total
+		}
+		if out.Start > out.End {
+			continue
+		}
+		expanded = append(expanded, out)
+	}
+	return mergeRanges(expanded)
+}
+
+// enclosingBlock finds the top-level declaration around r: the nearest
+// unindented line at or above r.Start, through the next unindented closing
+// brace at or below r.End.
+func enclosingBlock(lines []string, r LineRange) (LineRange, bool) {
+	start := 0
+	for i := min(r.Start, len(lines)); i >= 1 && r.End-i < maxEnclosingLines; i-- {
+		if isBlockStart(lines[i-1]) {
+			start = i
+			break
+		}
+	}
+	if start == 0 {
+		return LineRange{}, false
+	}
+	for i := max(r.End, start); i <= len(lines) && i-start < maxEnclosingLines; i++ {
+		if strings.HasPrefix(lines[i-1], "}") {
+			return LineRange{Start: start, End: i}, true
+		}
+	}
+	return LineRange{}, false
+}
+
+func isBlockStart(line string) bool {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '}' {
+		return false
+	}
+	return strings.HasSuffix(strings.TrimSpace(line), "{")
+}
+
+func mergeRanges(ranges []LineRange) []LineRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	merged := []LineRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End+1 {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// RenderExcerpt prints the given ranges of content with line numbers, under a
+// header naming the file and the revision it was read from.
+func RenderExcerpt(path string, label string, content string, ranges []LineRange) string {
+	lines := strings.Split(content, "\n")
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", path, label)
+	for i, r := range ranges {
+		if i > 0 {
+			b.WriteString("   ...\n")
+		}
+		for n := r.Start; n <= r.End && n <= len(lines); n++ {
+			fmt.Fprintf(&b, "%5d | %s\n", n, lines[n-1])
+		}
+	}
+	return b.String()
+}
+
+// ContextFiles selects the files eligible for context expansion using the same
+// ignore globs and file limit as BuildChunks.
+func ContextFiles(files []FileDiff, ignoreGlobs []string, maxFiles int) []FileDiff {
+	var selected []FileDiff
+	for _, file := range files {
+		if len(selected) >= maxFiles {
+			break
+		}
+		if file.Path == "" || isIgnored(file.Path, ignoreGlobs) {
+			continue
+		}
+		selected = append(selected, file)
+	}
+	return selected
+}
+
+// BuildContextChunks splits rendered excerpts into chunks no larger than
+// maxChunkChars, matching the chunk format used for diffs.
+func BuildContextChunks(path string, excerpt string, maxChunkChars int) []string {
+	return splitChunk(path, excerpt, maxChunkChars)
+}
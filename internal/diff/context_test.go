@@ -0,0 +1,62 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+const contextSource = `package auth
+
+import "errors"
+
+func Login(user string) error {
+	if user == "" {
+		return errors.New("empty user")
+	}
+	return nil
+}
+
+func Logout(user string) error {
+	return nil
+}`
+
+func TestHunkRanges(t *testing.T) {
+	file := FileDiff{Path: "auth.go", Text: "diff --git a/old.go b/auth.go\n--- a/old.go\n+++ b/auth.go\n@@ -5,3 +6,4 @@ func Login\n@@ -20,0 +22 @@\n"}
+	oldRanges, newRanges := HunkRanges(file)
+	if len(oldRanges) != 1 || oldRanges[0] != (LineRange{Start: 5, End: 7}) {
+		t.Fatalf("unexpected old ranges: %+v", oldRanges)
+	}
+	if len(newRanges) != 2 || newRanges[0] != (LineRange{Start: 6, End: 9}) || newRanges[1] != (LineRange{Start: 22, End: 22}) {
+		t.Fatalf("unexpected new ranges: %+v", newRanges)
+	}
+	if file.OldPath() != "old.go" {
+		t.Fatalf("unexpected old path: %s", file.OldPath())
+	}
+	if file.FileStatus() != "modified" {
+		t.Fatalf("unexpected status: %s", file.FileStatus())
+	}
+}
+
+func TestExpandRangesEnclosing(t *testing.T) {
+	ranges := ExpandRanges(contextSource, []LineRange{{Start: 7, End: 7}}, 1, true)
+	if len(ranges) != 1 || ranges[0] != (LineRange{Start: 5, End: 10}) {
+		t.Fatalf("expected the Login body, got %+v", ranges)
+	}
+}
+
+func TestExpandRangesLinesAndMerge(t *testing.T) {
+	ranges := ExpandRanges(contextSource, []LineRange{{Start: 2, End: 2}, {Start: 5, End: 5}, {Start: 14, End: 14}}, 1, false)
+	want := []LineRange{{Start: 1, End: 6}, {Start: 13, End: 14}}
+	if len(ranges) != len(want) || ranges[0] != want[0] || ranges[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, ranges)
+	}
+}
+
+func TestRenderExcerpt(t *testing.T) {
+	out := RenderExcerpt("auth.go", "head abc", contextSource, []LineRange{{Start: 1, End: 1}, {Start: 13, End: 13}})
+	for _, want := range []string{"auth.go (head abc)\n", "    1 | package auth\n", "   ...\n", "   13 | \treturn nil\n"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+}
@@ -27,3 +27,58 @@ func TestBuildChunks(t *testing.T) {
 		t.Fatalf("expected 1 chunk, got %d", len(chunks))
 	}
 }
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"internal/auth/**", "internal/auth/auth.go", true},
+		{"internal/auth/**", "internal/auth/oauth/token.go", true},
+		{"internal/auth/**", "internal/authz/auth.go", false},
+		{"**/*.sql", "migrations/0001_init.sql", true},
+		{"**/*.sql", "init.sql", true},
+		{"web/*.ts", "web/app/main.ts", false},
+		{"docs/**/*.md", "docs/guide/setup/intro.md", true},
+		{"go.mod", "go.mod", true},
+	}
+	for _, tc := range cases {
+		if got := MatchGlob(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMapLine(t *testing.T) {
+	patch := "@@ -2,3 +2,4 @@ func a() {\n x\n-y\n+y2\n+y3\n z\n@@ -20,2 +21,1 @@\n-p\n q"
+	cases := []struct {
+		line int
+		want int
+		ok   bool
+	}{
+		{1, 1, true},
+		{2, 2, true},
+		{3, 0, false},
+		{4, 5, true},
+		{10, 11, true},
+		{20, 0, false},
+		{21, 21, true},
+		{30, 30, true},
+	}
+	for _, tc := range cases {
+		got, ok := MapLine(patch, tc.line)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("MapLine(%d) = %d, %v; want %d, %v", tc.line, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestChangedLines(t *testing.T) {
+	file := FileDiff{Path: "a.go", Text: "--- a/a.go\n+++ b/a.go\n@@ -1,8 +1,8 @@\n a\n b\n c\n-d\n+D\n+E\n e\n-f\n g\n h"}
+	got := ChangedLines(file)
+	// Context lines are left out; the removal of f marks line 7.
+	want := []LineRange{{Start: 4, End: 5}, {Start: 7, End: 7}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("ChangedLines = %+v, want %+v", got, want)
+	}
+}
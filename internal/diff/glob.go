@@ -0,0 +1,50 @@
+package diff
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether a slash-separated file path matches pattern.
+// Segments match as in path.Match, and a "**" segment matches any number of
+// segments, including none, so "internal/auth/**" matches every file under
+// internal/auth and "**/*.sql" matches SQL files at any depth.
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// MatchAnyGlob returns the first of paths matched by any of patterns, and the
+// pattern that matched it.
+func MatchAnyGlob(patterns []string, paths []string) (pattern string, matched string, ok bool) {
+	for _, p := range paths {
+		for _, pattern := range patterns {
+			if MatchGlob(pattern, p) {
+				return pattern, p, true
+			}
+		}
+	}
+	return "", "", false
+}
@@ -12,18 +12,24 @@ var hunkHeaderRE = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))?
 type PositionMap struct {
 	NewLineToPosition map[string]map[int]int
 	OldLineToPosition map[string]map[int]int
+	// AddedLines holds the new-side lines each file's diff adds.
+	AddedLines map[string]map[int]bool
 }
 
 func BuildPositionMap(files []FileDiff) (PositionMap, error) {
 	pm := PositionMap{
 		NewLineToPosition: map[string]map[int]int{},
 		OldLineToPosition: map[string]map[int]int{},
+		AddedLines:        map[string]map[int]bool{},
 	}
 	for _, file := range files {
-		newMap, oldMap, err := buildFilePositionMaps(file.Text)
+		newMap, oldMap, added, err := buildFilePositionMaps(file.Text)
 		if err != nil {
 			return PositionMap{}, fmt.Errorf("build position map for %q: %w", file.Path, err)
 		}
+		if len(added) > 0 {
+			pm.AddedLines[file.Path] = added
+		}
 		if len(newMap) > 0 {
 			pm.NewLineToPosition[file.Path] = newMap
 		}
@@ -43,9 +49,16 @@ func (p PositionMap) PositionForNewLine(path string, line int) (int, bool) {
 	return pos, ok
 }
 
-func buildFilePositionMaps(unifiedFileDiff string) (newLineToPos map[int]int, oldLineToPos map[int]int, err error) {
+// IsAddedLine reports whether line of path is added by the diff, as opposed
+// to a context line.
+func (p PositionMap) IsAddedLine(path string, line int) bool {
+	return p.AddedLines[path][line]
+}
+
+func buildFilePositionMaps(unifiedFileDiff string) (newLineToPos map[int]int, oldLineToPos map[int]int, added map[int]bool, err error) {
 	newLineToPos = map[int]int{}
 	oldLineToPos = map[int]int{}
+	added = map[int]bool{}
 
 	pos := 0
 	oldLine := 0
@@ -61,11 +74,11 @@ func buildFilePositionMaps(unifiedFileDiff string) (newLineToPos map[int]int, ol
 		if len(matches) > 0 {
 			oldStart, parseErr := strconv.Atoi(matches[1])
 			if parseErr != nil {
-				return nil, nil, fmt.Errorf("invalid hunk header: %q", line)
+				return nil, nil, nil, fmt.Errorf("invalid hunk header: %q", line)
 			}
 			newStart, parseErr := strconv.Atoi(matches[3])
 			if parseErr != nil {
-				return nil, nil, fmt.Errorf("invalid hunk header: %q", line)
+				return nil, nil, nil, fmt.Errorf("invalid hunk header: %q", line)
 			}
 			oldLine = oldStart
 			newLine = newStart
@@ -86,6 +99,7 @@ func buildFilePositionMaps(unifiedFileDiff string) (newLineToPos map[int]int, ol
 			newLine++
 		case strings.HasPrefix(line, "+"):
 			newLineToPos[newLine] = pos
+			added[newLine] = true
 			newLine++
 		case strings.HasPrefix(line, "-"):
 			oldLineToPos[oldLine] = pos
@@ -97,5 +111,44 @@ func buildFilePositionMaps(unifiedFileDiff string) (newLineToPos map[int]int, ol
 		}
 	}
 
-	return newLineToPos, oldLineToPos, nil
+	return newLineToPos, oldLineToPos, added, nil
+}
+
+// MapLine maps an old-side line number through a single-file patch to its
+// new-side line number. It reports false when the patch removes or
+// replaces the line.
+func MapLine(patch string, line int) (int, bool) {
+	oldLine, newLine := 0, 0
+	inPatch := false
+	for _, text := range strings.Split(patch, "\n") {
+		if matches := hunkHeaderRE.FindStringSubmatch(text); len(matches) > 0 {
+			oldStart, _ := strconv.Atoi(matches[1])
+			newStart, _ := strconv.Atoi(matches[3])
+			if line < oldStart {
+				break
+			}
+			oldLine, newLine = oldStart, newStart
+			inPatch = true
+			continue
+		}
+		if !inPatch || text == "" {
+			continue
+		}
+		switch text[0] {
+		case ' ':
+			if oldLine == line {
+				return newLine, true
+			}
+			oldLine++
+			newLine++
+		case '-':
+			if oldLine == line {
+				return 0, false
+			}
+			oldLine++
+		case '+':
+			newLine++
+		}
+	}
+	return line + newLine - oldLine, true
 }
@@ -40,6 +40,9 @@ func TestBuildPositionMap_SingleHunk(t *testing.T) {
 	if !ok || pos != 6 {
 		t.Fatalf("expected new line 4 => pos 6, got %d (ok=%v)", pos, ok)
 	}
+	if pm.IsAddedLine("a.txt", 1) || !pm.IsAddedLine("a.txt", 2) || !pm.IsAddedLine("a.txt", 3) || pm.IsAddedLine("a.txt", 4) {
+		t.Fatalf("expected only new lines 2 and 3 to be added, got %v", pm.AddedLines["a.txt"])
+	}
 }
 
 func TestBuildPositionMap_MultiHunk(t *testing.T) {
@@ -32,6 +32,22 @@ func (c *Client) AuthStatus(ctx context.Context) error {
 	return err
 }
 
+// CurrentUser returns the login of the authenticated gh user.
+func (c *Client) CurrentUser(ctx context.Context) (string, error) {
+	output, err := c.Runner.Run(ctx, []string{"api", "user"}, nil)
+	if err != nil {
+		return "", err
+	}
+	var user UserRef
+	if err := json.Unmarshal(output, &user); err != nil {
+		return "", fmt.Errorf("failed to decode gh user: %w", err)
+	}
+	if strings.TrimSpace(user.Login) == "" {
+		return "", fmt.Errorf("gh user has no login")
+	}
+	return user.Login, nil
+}
+
 type SearchPRsResponse []SearchPRItem
 
 type SearchPRItem struct {
@@ -148,27 +164,89 @@ func (c *Client) PRDiff(ctx context.Context, pr string) (string, error) {
 	return string(output), nil
 }
 
-type CheckRunsResponse struct {
-	Total int        `json:"total"`
-	Runs  []CheckRun `json:"check_runs"`
+// PRHead is a PR's head commit and the rollup of its checks and commit
+// statuses.
+type PRHead struct {
+	SHA string
+	// Checks is one of success, failure, pending, or none.
+	Checks string
 }
 
-type CheckRun struct {
-	Status     string `json:"status"`
-	Conclusion string `json:"conclusion"`
-}
+// prHeadsBatch bounds how many PRs are looked up per GraphQL request.
+const prHeadsBatch = 50
 
-func (c *Client) CheckRuns(ctx context.Context, repo string, sha string) (CheckRunsResponse, error) {
-	args := []string{"api", fmt.Sprintf("repos/%s/commits/%s/check-runs", repo, sha)}
-	output, err := c.Runner.Run(ctx, args, nil)
-	if err != nil {
-		return CheckRunsResponse{}, err
+// PRHeads returns the head of each PR, keyed by "OWNER/REPO#N". PRs are
+// looked up in batches with one GraphQL request each, rather than one
+// request per PR. PRs that cannot be found are left out.
+func (c *Client) PRHeads(ctx context.Context, refs []string) (map[string]PRHead, error) {
+	heads := map[string]PRHead{}
+	for start := 0; start < len(refs); start += prHeadsBatch {
+		batch := refs[start:min(start+prHeadsBatch, len(refs))]
+		var b strings.Builder
+		b.WriteString("query {\n")
+		for i, ref := range batch {
+			repo, number, err := ParsePR(ref)
+			if err != nil {
+				return nil, err
+			}
+			owner, name, err := splitRepo(repo)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&b, "  pr%d: repository(owner: %s, name: %s) { pullRequest(number: %d) { headRefOid commits(last: 1) { nodes { commit { statusCheckRollup { state } } } } } }\n",
+				i, strconv.Quote(owner), strconv.Quote(name), number)
+		}
+		b.WriteString("}")
+		output, err := c.Runner.Run(ctx, []string{"api", "graphql", "-f", "query=" + b.String()}, nil)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Data map[string]*struct {
+				PullRequest *struct {
+					HeadRefOid string `json:"headRefOid"`
+					Commits    struct {
+						Nodes []struct {
+							Commit struct {
+								StatusCheckRollup *struct {
+									State string `json:"state"`
+								} `json:"statusCheckRollup"`
+							} `json:"commit"`
+						} `json:"nodes"`
+					} `json:"commits"`
+				} `json:"pullRequest"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(output, &resp); err != nil {
+			return nil, fmt.Errorf("failed to decode PR heads: %w", err)
+		}
+		for i, ref := range batch {
+			repoNode := resp.Data[fmt.Sprintf("pr%d", i)]
+			if repoNode == nil || repoNode.PullRequest == nil {
+				continue
+			}
+			head := PRHead{SHA: repoNode.PullRequest.HeadRefOid, Checks: "none"}
+			if nodes := repoNode.PullRequest.Commits.Nodes; len(nodes) > 0 && nodes[0].Commit.StatusCheckRollup != nil {
+				head.Checks = rollupChecks(nodes[0].Commit.StatusCheckRollup.State)
+			}
+			heads[ref] = head
+		}
 	}
-	var resp CheckRunsResponse
-	if err := json.Unmarshal(output, &resp); err != nil {
-		return CheckRunsResponse{}, fmt.Errorf("failed to decode check-runs output: %w", err)
+	return heads, nil
+}
+
+// rollupChecks maps a GraphQL StatusState to the queue's check states.
+func rollupChecks(state string) string {
+	switch state {
+	case "SUCCESS":
+		return "success"
+	case "FAILURE", "ERROR":
+		return "failure"
+	case "PENDING", "EXPECTED":
+		return "pending"
+	default:
+		return "none"
 	}
-	return resp, nil
 }
 
 var prRefRe = regexp.MustCompile(`^([^/]+/[^#]+)#([0-9]+)$`)
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned, wrapped, for a path that does not exist at a ref.
+var ErrNotFound = errors.New("not found")
+
+type contentsResponse struct {
+	Type     string `json:"type"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// FileContents returns the contents of path in repo at ref.
+func (c *Client) FileContents(ctx context.Context, repo, path, ref string) (string, error) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	endpoint := fmt.Sprintf("repos/%s/contents/%s?ref=%s", repo, strings.Join(segments, "/"), url.QueryEscape(ref))
+	// --include prints the HTTP status, so a missing file is told apart from
+	// other failures without parsing gh's error text.
+	output, err := c.Runner.Run(ctx, []string{"api", "--include", endpoint}, nil)
+	if err != nil {
+		var runErr *RunError
+		if errors.As(err, &runErr) {
+			if status, _, ok := splitHTTPResponse(runErr.Output); ok && status == http.StatusNotFound {
+				return "", fmt.Errorf("%s at %s: %w", path, ref, ErrNotFound)
+			}
+		}
+		return "", err
+	}
+	status, body, ok := splitHTTPResponse(output)
+	if !ok || status != http.StatusOK {
+		return "", fmt.Errorf("unexpected response for contents of %s: %.80q", path, output)
+	}
+	var resp contentsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("decode contents of %s: %w", path, err)
+	}
+	if resp.Type != "file" {
+		return "", fmt.Errorf("%s at %s is a %s, not a file", path, ref, resp.Type)
+	}
+	if resp.Encoding != "base64" {
+		return "", fmt.Errorf("unsupported encoding %q for %s", resp.Encoding, path)
+	}
+	data, err := base64.StdEncoding.DecodeString(stripNewlines(resp.Content))
+	if err != nil {
+		return "", fmt.Errorf("decode contents of %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// HeadCommitSHA returns the SHA at the head of repo's default branch.
+func (c *Client) HeadCommitSHA(ctx context.Context, repo string) (string, error) {
+	output, err := c.Runner.Run(ctx, []string{"api", fmt.Sprintf("repos/%s/commits/HEAD", repo)}, nil)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		SHA string `json:"sha"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return "", fmt.Errorf("decode head commit of %s: %w", repo, err)
+	}
+	if resp.SHA == "" {
+		return "", fmt.Errorf("no head commit returned for %s", repo)
+	}
+	return resp.SHA, nil
+}
+
+// splitHTTPResponse parses the output of `gh api --include` into the status
+// code and the body following the headers.
+func splitHTTPResponse(output []byte) (int, []byte, bool) {
+	head, body, ok := bytes.Cut(output, []byte("\n\n"))
+	if crlfHead, crlfBody, found := bytes.Cut(output, []byte("\r\n\r\n")); found && (!ok || len(crlfHead) < len(head)) {
+		head, body, ok = crlfHead, crlfBody, true
+	}
+	if !ok {
+		return 0, nil, false
+	}
+	statusLine, _, _ := bytes.Cut(head, []byte("\n"))
+	fields := strings.Fields(string(statusLine))
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "HTTP/") {
+		return 0, nil, false
+	}
+	status, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, nil, false
+	}
+	return status, body, true
+}
+
+// stripNewlines removes the line breaks GitHub inserts into base64 content.
+func stripNewlines(s string) string {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\n' && s[i] != '\r' {
+			out = append(out, s[i])
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,66 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileContents(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "gh", "contents.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	runner := &recordingRunner{Output: append([]byte("HTTP/2.0 200 OK\r\nContent-Type: application/json\r\n\r\n"), data...)}
+	client := NewClient(runner)
+	content, err := client.FileContents(context.Background(), "acme/app", "internal/auth/auth.go", "head5678")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(content, "package auth\n") || !strings.Contains(content, "func Login(user string) error {") {
+		t.Fatalf("unexpected content: %q", content)
+	}
+	if got := strings.Join(runner.Args, " "); got != "api --include repos/acme/app/contents/internal/auth/auth.go?ref=head5678" {
+		t.Fatalf("unexpected args: %s", got)
+	}
+}
+
+func TestFileContentsEscapesPath(t *testing.T) {
+	runner := &recordingRunner{Output: []byte("HTTP/2.0 200 OK\n\n" + `{"type":"file","encoding":"base64","content":"eA=="}`)}
+	client := NewClient(runner)
+	if _, err := client.FileContents(context.Background(), "acme/app", "docs/a b#1?.md", "main"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(runner.Args, " "); got != "api --include repos/acme/app/contents/docs/a%20b%231%3F.md?ref=main" {
+		t.Fatalf("unexpected args: %s", got)
+	}
+}
+
+func TestFileContentsRejectsDirectory(t *testing.T) {
+	client := NewClient(&recordingRunner{Output: []byte("HTTP/2.0 200 OK\n\n" + `{"type":"dir"}`)})
+	if _, err := client.FileContents(context.Background(), "acme/app", "internal", "head5678"); err == nil {
+		t.Fatalf("expected an error for a directory")
+	}
+}
+
+func TestFileContentsNotFound(t *testing.T) {
+	client := NewClient(NewFixtureRunner(t.TempDir()))
+	if _, err := client.FileContents(context.Background(), "acme/app", "prq.yaml", "main"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// Other failures, even ones mentioning a 404 in their text, are not.
+	runner := failingRunner{err: &RunError{Args: []string{"api"}, Output: []byte("HTTP/2.0 403 Forbidden\n\n{\"message\":\"see (HTTP 404)\"}"), Err: errors.New("exit status 1")}}
+	if _, err := NewClient(runner).FileContents(context.Background(), "acme/app", "prq.yaml", "main"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a non-404 error, got %v", err)
+	}
+}
+
+type failingRunner struct{ err error }
+
+func (r failingRunner) Run(ctx context.Context, args []string, stdin []byte) ([]byte, error) {
+	return nil, r.err
+}
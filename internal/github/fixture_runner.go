@@ -2,9 +2,14 @@ package github
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -27,14 +32,28 @@ func (f FixtureRunner) Run(ctx context.Context, args []string, stdin []byte) ([]
 		file = "pr_view.json"
 	} else if strings.Contains(key, "pr diff") {
 		file = "pr_diff.txt"
-	} else if strings.Contains(key, "check-runs") {
-		file = "check_runs.json"
+	} else if strings.Contains(key, "api graphql") && strings.Contains(key, "statusCheckRollup") {
+		file = "pr_heads.json"
+	} else if strings.Contains(key, "api graphql") && strings.Contains(key, "unresolveReviewThread") {
+		file = "unresolve_thread.json"
+	} else if strings.Contains(key, "api graphql") && strings.Contains(key, "resolveReviewThread") {
+		file = "resolve_thread.json"
+	} else if key == "api user" {
+		file = "user.json"
+	} else if strings.Contains(key, "api graphql") && strings.Contains(key, "addPullRequestReviewThreadReply") {
+		file = "reply_thread.json"
 	} else if strings.Contains(key, "api graphql") && strings.Contains(key, "reviewThreads") {
 		file = "review_threads.json"
+	} else if strings.Contains(key, "/contents/") {
+		return f.contents(args)
+	} else if strings.Contains(key, "/commits/HEAD") {
+		file = "head_commit.json"
 	} else if strings.Contains(key, "compare/") {
 		file = "compare.json"
 	} else if strings.Contains(key, "api -X POST") && strings.Contains(key, "/pulls/") && strings.Contains(key, "/reviews") {
 		file = "create_review.json"
+	} else if strings.Contains(key, "/pulls/") && strings.Contains(key, "/reviews") {
+		file = "reviews.json"
 	} else if strings.Contains(key, "auth status") {
 		return []byte("logged in"), nil
 	} else {
@@ -43,3 +62,44 @@ func (f FixtureRunner) Run(ctx context.Context, args []string, stdin []byte) ([]
 	path := filepath.Join(f.Root, file)
 	return os.ReadFile(path)
 }
+
+// contents serves Root/contents/<path> when that file exists, and otherwise
+// contents.json if it describes the requested path, in the form printed by
+// `gh api --include`. Any other path fails with a 404, as gh reports it.
+func (f FixtureRunner) contents(args []string) ([]byte, error) {
+	key := strings.Join(args, " ")
+	_, rest, _ := strings.Cut(key, "/contents/")
+	escaped, _, _ := strings.Cut(rest, "?")
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := os.ReadFile(filepath.Join(f.Root, "contents", filepath.FromSlash(path))); err == nil {
+		body, err := json.Marshal(contentsResponse{Type: "file", Encoding: "base64", Content: base64.StdEncoding.EncodeToString(data)})
+		if err != nil {
+			return nil, err
+		}
+		return includeHeaders(args, "200 OK", body), nil
+	}
+	data, err := os.ReadFile(filepath.Join(f.Root, "contents.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var described struct {
+		Path string `json:"path"`
+	}
+	if err == nil && json.Unmarshal(data, &described) == nil && (described.Path == "" || described.Path == path) {
+		return includeHeaders(args, "200 OK", data), nil
+	}
+	output := includeHeaders(args, "404 Not Found", []byte(`{"message":"Not Found"}`))
+	return nil, &RunError{Args: args, Output: append(output, "\ngh: Not Found (HTTP 404)\n"...), Err: errors.New("exit status 1")}
+}
+
+// includeHeaders prefixes body with a status line and headers when args
+// ask for them with --include.
+func includeHeaders(args []string, status string, body []byte) []byte {
+	if !slices.Contains(args, "--include") {
+		return body
+	}
+	return append([]byte("HTTP/2.0 "+status+"\nContent-Type: application/json; charset=utf-8\n\n"), body...)
+}
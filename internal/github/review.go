@@ -1,9 +1,11 @@
 package github
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 )
 
 type ReviewComment struct {
@@ -43,3 +45,34 @@ func (c *Client) CreateReview(ctx context.Context, repo string, number int, req
 	}
 	return resp, nil
 }
+
+// Review is a submitted top-level pull request review.
+type Review struct {
+	ID          int64   `json:"id"`
+	User        UserRef `json:"user"`
+	Body        string  `json:"body"`
+	State       string  `json:"state"`
+	SubmittedAt string  `json:"submitted_at"`
+}
+
+// Reviews returns every review on the PR. gh --paginate prints one JSON
+// array per page, so the arrays are decoded in turn.
+func (c *Client) Reviews(ctx context.Context, repo string, number int) ([]Review, error) {
+	args := []string{"api", "--paginate", fmt.Sprintf("repos/%s/pulls/%d/reviews?per_page=100", repo, number)}
+	output, err := c.Runner.Run(ctx, args, nil)
+	if err != nil {
+		return nil, err
+	}
+	var reviews []Review
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var page []Review
+		if err := dec.Decode(&page); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decode reviews output: %w", err)
+		}
+		reviews = append(reviews, page...)
+	}
+	return reviews, nil
+}
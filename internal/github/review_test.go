@@ -53,3 +53,19 @@ func TestCreateReview(t *testing.T) {
 		t.Fatalf("unexpected request payload: %#v", got)
 	}
 }
+
+func TestReviewsPaginates(t *testing.T) {
+	runner := &recordingRunner{Output: []byte("[{\"id\":1,\"body\":\"first\"}]\n[{\"id\":2,\"body\":\"second\"}]")}
+	client := NewClient(runner)
+
+	reviews, err := client.Reviews(context.Background(), "acme/app", 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reviews) != 2 || reviews[1].Body != "second" {
+		t.Fatalf("expected reviews from both pages, got %+v", reviews)
+	}
+	if runner.Args[1] != "--paginate" {
+		t.Fatalf("expected --paginate, got %v", runner.Args)
+	}
+}
@@ -8,18 +8,24 @@ import (
 )
 
 type ReviewThread struct {
+	ID         string
 	IsResolved bool
 	IsOutdated bool
 	Path       string
+	StartLine  *int
 	Line       *int
-	Comments   []ReviewThreadComment
-	Count      int
+	// DiffHunk is the hunk the thread was started on, taken from its first comment.
+	DiffHunk string
+	Comments []ReviewThreadComment
+	Count    int
 }
 
 type ReviewThreadComment struct {
+	ID        string
 	Author    string
 	Body      string
 	CreatedAt string
+	URL       string
 }
 
 type reviewThreadsResponse struct {
@@ -36,9 +42,11 @@ type reviewThreadsResponse struct {
 }
 
 type reviewThreadNode struct {
-	IsResolved bool `json:"isResolved"`
-	IsOutdated bool `json:"isOutdated"`
+	ID         string `json:"id"`
+	IsResolved bool   `json:"isResolved"`
+	IsOutdated bool   `json:"isOutdated"`
 	Path       string
+	StartLine  *int `json:"startLine"`
 	Line       *int `json:"line"`
 	Comments   struct {
 		Nodes      []reviewThreadCommentNode `json:"nodes"`
@@ -47,11 +55,14 @@ type reviewThreadNode struct {
 }
 
 type reviewThreadCommentNode struct {
+	ID     string `json:"id"`
 	Author struct {
 		Login string `json:"login"`
 	} `json:"author"`
 	Body      string `json:"body"`
 	CreatedAt string `json:"createdAt"`
+	DiffHunk  string `json:"diffHunk"`
+	URL       string `json:"url"`
 }
 
 type pageInfo struct {
@@ -70,15 +81,20 @@ func (c *Client) ReviewThreads(ctx context.Context, repo string, number int) ([]
     pullRequest(number: $number) {
       reviewThreads(first: 100, after: $after) {
         nodes {
+          id
           isResolved
           isOutdated
           path
+          startLine
           line
-          comments(last: 1) {
+          comments(first: 100) {
             nodes {
+              id
               author { login }
               body
               createdAt
+              diffHunk
+              url
             }
             totalCount
           }
@@ -110,17 +126,24 @@ func (c *Client) ReviewThreads(ctx context.Context, repo string, number int) ([]
 
 		for _, node := range resp.Data.Repository.PullRequest.ReviewThreads.Nodes {
 			thread := ReviewThread{
+				ID:         node.ID,
 				IsResolved: node.IsResolved,
 				IsOutdated: node.IsOutdated,
 				Path:       node.Path,
+				StartLine:  node.StartLine,
 				Line:       node.Line,
 				Count:      node.Comments.TotalCount,
 			}
 			for _, comment := range node.Comments.Nodes {
+				if thread.DiffHunk == "" {
+					thread.DiffHunk = comment.DiffHunk
+				}
 				thread.Comments = append(thread.Comments, ReviewThreadComment{
+					ID:        comment.ID,
 					Author:    comment.Author.Login,
 					Body:      comment.Body,
 					CreatedAt: comment.CreatedAt,
+					URL:       comment.URL,
 				})
 			}
 			threads = append(threads, thread)
@@ -136,6 +159,100 @@ func (c *Client) ReviewThreads(ctx context.Context, repo string, number int) ([]
 	return threads, nil
 }
 
+type addThreadReplyResponse struct {
+	Data struct {
+		AddPullRequestReviewThreadReply struct {
+			Comment reviewThreadCommentNode `json:"comment"`
+		} `json:"addPullRequestReviewThreadReply"`
+	} `json:"data"`
+}
+
+// ReplyToThread posts body as a reply in the review thread with the given
+// GraphQL node ID.
+func (c *Client) ReplyToThread(ctx context.Context, threadID string, body string) (ReviewThreadComment, error) {
+	if strings.TrimSpace(threadID) == "" {
+		return ReviewThreadComment{}, fmt.Errorf("thread id is required")
+	}
+	query := `mutation($threadId: ID!, $body: String!) {
+  addPullRequestReviewThreadReply(input: {pullRequestReviewThreadId: $threadId, body: $body}) {
+    comment {
+      id
+      author { login }
+      body
+      createdAt
+      url
+    }
+  }
+}`
+	args := []string{"api", "graphql", "-f", "query=" + query, "-f", "threadId=" + threadID, "-f", "body=" + body}
+	output, err := c.Runner.Run(ctx, args, nil)
+	if err != nil {
+		return ReviewThreadComment{}, err
+	}
+	var resp addThreadReplyResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return ReviewThreadComment{}, fmt.Errorf("failed to decode thread reply: %w", err)
+	}
+	comment := resp.Data.AddPullRequestReviewThreadReply.Comment
+	return ReviewThreadComment{
+		ID:        comment.ID,
+		Author:    comment.Author.Login,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+		URL:       comment.URL,
+	}, nil
+}
+
+type setThreadResolvedResponse struct {
+	Data map[string]struct {
+		Thread struct {
+			ID         string `json:"id"`
+			IsResolved bool   `json:"isResolved"`
+		} `json:"thread"`
+	} `json:"data"`
+}
+
+// ResolveThread marks the review thread with the given node ID as resolved.
+func (c *Client) ResolveThread(ctx context.Context, threadID string) error {
+	return c.setThreadResolved(ctx, threadID, "resolveReviewThread", true)
+}
+
+// UnresolveThread reopens a previously resolved review thread.
+func (c *Client) UnresolveThread(ctx context.Context, threadID string) error {
+	return c.setThreadResolved(ctx, threadID, "unresolveReviewThread", false)
+}
+
+func (c *Client) setThreadResolved(ctx context.Context, threadID string, mutation string, want bool) error {
+	if strings.TrimSpace(threadID) == "" {
+		return fmt.Errorf("thread id is required")
+	}
+	query := fmt.Sprintf(`mutation($threadId: ID!) {
+  %s(input: {threadId: $threadId}) {
+    thread {
+      id
+      isResolved
+    }
+  }
+}`, mutation)
+	args := []string{"api", "graphql", "-f", "query=" + query, "-f", "threadId=" + threadID}
+	output, err := c.Runner.Run(ctx, args, nil)
+	if err != nil {
+		return err
+	}
+	var resp setThreadResolvedResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", mutation, err)
+	}
+	result, ok := resp.Data[mutation]
+	if !ok {
+		return fmt.Errorf("%s response missing data", mutation)
+	}
+	if result.Thread.IsResolved != want {
+		return fmt.Errorf("%s did not change thread %s", mutation, threadID)
+	}
+	return nil
+}
+
 func splitRepo(repo string) (string, string, error) {
 	parts := strings.Split(repo, "/")
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
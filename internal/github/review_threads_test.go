@@ -0,0 +1,81 @@
+package github
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReviewThreadsFullConversation(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "gh", "review_threads.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	client := NewClient(fakeRunner{Output: data})
+	threads, err := client.ReviewThreads(context.Background(), "acme/app", 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(threads) != 1 {
+		t.Fatalf("expected 1 thread, got %d", len(threads))
+	}
+	thread := threads[0]
+	if thread.ID != "PRRT_kwDOAuth42" {
+		t.Fatalf("unexpected thread id: %q", thread.ID)
+	}
+	if len(thread.Comments) != 3 {
+		t.Fatalf("expected 3 comments, got %d", len(thread.Comments))
+	}
+	if thread.Comments[0].Author != "reviewer" || thread.Comments[0].ID != "PRRC_kwDOAuth1" {
+		t.Fatalf("unexpected first comment: %#v", thread.Comments[0])
+	}
+	if !strings.HasPrefix(thread.DiffHunk, "@@ -1,4 +1,4 @@") {
+		t.Fatalf("unexpected diff hunk: %q", thread.DiffHunk)
+	}
+}
+
+func TestReplyToThread(t *testing.T) {
+	runner := &recordingRunner{Output: []byte(`{"data":{"addPullRequestReviewThreadReply":{"comment":{"id":"C1","author":{"login":"me"},"body":"Done","url":"https://example.test/c1"}}}}`)}
+	client := NewClient(runner)
+	comment, err := client.ReplyToThread(context.Background(), "PRRT_1", "Done")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if comment.ID != "C1" || comment.URL != "https://example.test/c1" {
+		t.Fatalf("unexpected comment: %#v", comment)
+	}
+	joined := strings.Join(runner.Args, " ")
+	if !strings.Contains(joined, "addPullRequestReviewThreadReply") || !strings.Contains(joined, "threadId=PRRT_1") || !strings.Contains(joined, "body=Done") {
+		t.Fatalf("unexpected args: %#v", runner.Args)
+	}
+	if _, err := client.ReplyToThread(context.Background(), "", "Done"); err == nil {
+		t.Fatalf("expected error for empty thread id")
+	}
+}
+
+func TestResolveAndUnresolveThread(t *testing.T) {
+	runner := &recordingRunner{Output: []byte(`{"data":{"resolveReviewThread":{"thread":{"id":"PRRT_1","isResolved":true}}}}`)}
+	client := NewClient(runner)
+	if err := client.ResolveThread(context.Background(), "PRRT_1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if joined := strings.Join(runner.Args, " "); !strings.Contains(joined, "resolveReviewThread(input: {threadId: $threadId})") || !strings.Contains(joined, "threadId=PRRT_1") {
+		t.Fatalf("unexpected args: %#v", runner.Args)
+	}
+
+	runner.Output = []byte(`{"data":{"unresolveReviewThread":{"thread":{"id":"PRRT_1","isResolved":false}}}}`)
+	if err := client.UnresolveThread(context.Background(), "PRRT_1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if joined := strings.Join(runner.Args, " "); !strings.Contains(joined, "unresolveReviewThread") {
+		t.Fatalf("unexpected args: %#v", runner.Args)
+	}
+
+	// A response that leaves the thread in the wrong state is an error.
+	runner.Output = []byte(`{"data":{"resolveReviewThread":{"thread":{"id":"PRRT_1","isResolved":false}}}}`)
+	if err := client.ResolveThread(context.Background(), "PRRT_1"); err == nil {
+		t.Fatalf("expected error when thread stays unresolved")
+	}
+}
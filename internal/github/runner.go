@@ -20,7 +20,21 @@ func (r RealRunner) Run(ctx context.Context, args []string, stdin []byte) ([]byt
 	}
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, fmt.Errorf("gh %v failed: %w\n%s", args, err, string(output))
+		return nil, &RunError{Args: args, Output: output, Err: err}
 	}
 	return output, nil
 }
+
+// RunError is a failed gh command. Output holds what it printed, which for
+// `gh api --include` starts with the HTTP status line.
+type RunError struct {
+	Args   []string
+	Output []byte
+	Err    error
+}
+
+func (e *RunError) Error() string {
+	return fmt.Sprintf("gh %v failed: %v\n%s", e.Args, e.Err, string(e.Output))
+}
+
+func (e *RunError) Unwrap() error { return e.Err }
@@ -0,0 +1,105 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/brianndofor/prq"
+)
+
+// Where an Asset was loaded from. A review profile's own file comes first,
+// then the others in resolution order.
+const (
+	SourceProfile  = "profile"
+	SourceEnv      = "env"
+	SourceRepo     = "repo"
+	SourceUser     = "user"
+	SourceEmbedded = "embedded"
+)
+
+// Asset is a prompt template or JSON schema and where it came from.
+type Asset struct {
+	// Name is the file name, e.g. "code-reviewer.txt".
+	Name string
+	// Source is one of SourceEnv, SourceRepo, SourceUser, or SourceEmbedded.
+	Source string
+	// Path is the file read; empty for embedded assets.
+	Path    string
+	Content []byte
+	// env is the variable an env-sourced Path came from.
+	env string
+}
+
+// Origin describes where the asset came from, such as
+// "env PRQ_PROMPT_PATH (/tmp/p.txt)" or "embedded".
+func (a Asset) Origin() string {
+	switch a.Source {
+	case SourceEmbedded:
+		return SourceEmbedded
+	case SourceEnv:
+		return fmt.Sprintf("%s %s (%s)", a.Source, a.env, a.Path)
+	}
+	return fmt.Sprintf("%s (%s)", a.Source, a.Path)
+}
+
+// AssetSpec names an overridable asset.
+type AssetSpec struct {
+	// Env overrides the path outright.
+	Env string
+	// Dir is the embedded directory, "prompts" or "schemas".
+	Dir  string
+	Name string
+}
+
+var (
+	ReviewPromptSpec   = AssetSpec{Env: "PRQ_PROMPT_PATH", Dir: "prompts", Name: "code-reviewer.txt"}
+	FollowupPromptSpec = AssetSpec{Env: "PRQ_FOLLOWUP_PROMPT_PATH", Dir: "prompts", Name: "followup-verifier.txt"}
+	ReviewSchemaSpec   = AssetSpec{Env: "PRQ_SCHEMA_PATH", Dir: "schemas", Name: "review_plan.schema.json"}
+	FollowupSchemaSpec = AssetSpec{Env: "PRQ_FOLLOWUP_SCHEMA_PATH", Dir: "schemas", Name: "followup_check.schema.json"}
+)
+
+// AssetSpecs lists every overridable asset, for reporting.
+var AssetSpecs = []AssetSpec{ReviewPromptSpec, FollowupPromptSpec, ReviewSchemaSpec, FollowupSchemaSpec}
+
+// Resolve loads the asset from the first of: the spec's environment
+// variable, .prq/<name> in the repo (the working directory, like prq.yaml),
+// ~/.prq/<name>, and the copy embedded in the binary. A path set in the
+// environment must exist.
+func Resolve(spec AssetSpec) (Asset, error) {
+	if p := os.Getenv(spec.Env); p != "" {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return Asset{}, fmt.Errorf("failed to read %s from %s: %w", spec.Name, spec.Env, err)
+		}
+		return Asset{Name: spec.Name, Source: SourceEnv, Path: p, Content: content, env: spec.Env}, nil
+	}
+	overrides := []struct{ source, path string }{
+		{SourceRepo, filepath.Join(".prq", spec.Name)},
+		{SourceUser, filepath.Join(os.Getenv("HOME"), ".prq", spec.Name)},
+	}
+	for _, o := range overrides {
+		content, err := os.ReadFile(o.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Asset{}, fmt.Errorf("failed to read %s: %w", o.path, err)
+		}
+		return Asset{Name: spec.Name, Source: o.source, Path: o.path, Content: content}, nil
+	}
+	content, err := prq.Assets.ReadFile(path.Join(spec.Dir, spec.Name))
+	if err != nil {
+		return Asset{}, fmt.Errorf("embedded %s: %w", spec.Name, err)
+	}
+	return Asset{Name: spec.Name, Source: SourceEmbedded, Content: content}, nil
+}
+
+// ProfileAsset is content named by a review profile in place of spec, read
+// from path.
+func ProfileAsset(spec AssetSpec, path string, content []byte) Asset {
+	return Asset{Name: spec.Name, Source: SourceProfile, Path: path, Content: content}
+}
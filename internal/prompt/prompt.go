@@ -2,55 +2,186 @@ package prompt
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 )
 
+// Snapshot is the data rendered into the review prompt. Text fields are empty
+// when the data was not collected, so a template can leave the section out.
 type Snapshot struct {
-	Repo          string
-	PRNumber      int
-	Title         string
-	Description   string
-	BaseSHA       string
-	HeadSHA       string
-	CISummary     string
-	TestResults   string
-	FileListStats string
-	DiffChunks    string
-}
-
-func LoadTemplate() (string, error) {
-	path := os.Getenv("PRQ_PROMPT_PATH")
-	if path == "" {
-		path = filepath.Join("prompts", "code-reviewer.txt")
+	// UserRules and RepoRules are rendered one per line by {{rules}}.
+	UserRules []string
+	RepoRules []string
+	// Profile names the review profile, if any, and ProfileRules are its
+	// focus rules.
+	Profile      string
+	ProfileRules []string
+
+	Repo        string
+	PRNumber    int
+	Title       string
+	Description string
+	BaseSHA     string
+	HeadSHA     string
+	// ReviewScope says whether the diff is the whole PR or only the changes
+	// since a previous review.
+	ReviewScope    string
+	PreviousIssues string
+	// ExistingComments lists review threads and reviews already on the PR.
+	ExistingComments string
+	CISummary        string
+	TestResults      string
+	// AnalyzerFindings lists static analyzer findings on changed lines.
+	AnalyzerFindings string
+	FileListStats    string
+	DiffChunks       string
+	// CodeContext holds the file contents surrounding each hunk when context
+	// expansion is enabled.
+	CodeContext string
+	// RelatedCode holds definitions and references of changed Go symbols.
+	RelatedCode string
+}
+
+// LoadTemplate resolves the review prompt template; see Resolve.
+func LoadTemplate() (Asset, error) {
+	return Resolve(ReviewPromptSpec)
+}
+
+// FollowupSnapshot is the data rendered into the follow-up verification prompt.
+type FollowupSnapshot struct {
+	Repo            string
+	PRNumber        int
+	LastReviewedSHA string
+	HeadSHA         string
+	Threads         string
+	IncrementalDiff string
+}
+
+// LoadFollowupTemplate resolves the follow-up prompt template.
+func LoadFollowupTemplate() (Asset, error) {
+	return Resolve(FollowupPromptSpec)
+}
+
+// RenderFollowup renders the follow-up prompt; see Render for the template
+// syntax.
+func RenderFollowup(text string, snap FollowupSnapshot) (string, error) {
+	if isLegacy(text) {
+		return renderLegacy(text, map[string]string{
+			"REPO":              snap.Repo,
+			"PR_NUMBER":         strconv.Itoa(snap.PRNumber),
+			"LAST_REVIEWED_SHA": snap.LastReviewedSHA,
+			"HEAD_SHA":          snap.HeadSHA,
+			"THREADS":           snap.Threads,
+			"INCREMENTAL_DIFF":  snap.IncrementalDiff,
+		}), nil
 	}
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return "", fmt.Errorf("failed to read prompt template: %w", err)
+	return execute("followup prompt", text, snap)
+}
+
+// Render renders the review prompt. Templates use text/template syntax over a
+// Snapshot, with the functions fence, quote, and rules for untrusted blocks,
+// untrusted single-line values, and rule lists. A template without any "{{"
+// is a legacy template whose {PLACEHOLDER}s are substituted in one pass, so
+// PR content that contains a placeholder is never expanded.
+func Render(text string, snap Snapshot) (string, error) {
+	if isLegacy(text) {
+		return renderLegacy(text, snap.legacyValues()), nil
 	}
-	return string(content), nil
+	return execute("prompt", text, snap)
 }
 
-func Render(template string, userRules []string, repoRules []string, snap Snapshot) string {
-	userRulesBlock := renderRules(userRules)
-	repoRulesBlock := renderRules(repoRules)
+// legacyValues maps the {PLACEHOLDER} names to their values, filling in
+// the text legacy templates showed for data that was not collected.
+func (s Snapshot) legacyValues() map[string]string {
+	return map[string]string{
+		"USER_RULES":           renderRules(s.UserRules),
+		"REPO_RULES":           renderRules(s.RepoRules),
+		"PROFILE":              s.Profile,
+		"PROFILE_RULES":        renderRules(s.ProfileRules),
+		"REPO":                 s.Repo,
+		"PR_NUMBER":            strconv.Itoa(s.PRNumber),
+		"TITLE":                s.Title,
+		"DESCRIPTION":          s.Description,
+		"BASE_SHA":             s.BaseSHA,
+		"HEAD_SHA":             s.HeadSHA,
+		"REVIEW_SCOPE":         s.ReviewScope,
+		"PREVIOUS_ISSUES":      orDefault(s.PreviousIssues, "None"),
+		"EXISTING_COMMENTS":    orDefault(s.ExistingComments, "None"),
+		"CI_SUMMARY":           orDefault(s.CISummary, "Not fetched"),
+		"TEST_RESULTS":         orDefault(s.TestResults, "Not run"),
+		"ANALYZER_FINDINGS":    orDefault(s.AnalyzerFindings, "Not run"),
+		"FILE_LIST_WITH_STATS": s.FileListStats,
+		"DIFF_CHUNKS":          s.DiffChunks,
+		"CODE_CONTEXT":         orDefault(s.CodeContext, "Not expanded"),
+		"RELATED_CODE":         orDefault(s.RelatedCode, "Not collected"),
+	}
+}
 
-	out := template
-	out = strings.ReplaceAll(out, "{USER_RULES}", userRulesBlock)
-	out = strings.ReplaceAll(out, "{REPO_RULES}", repoRulesBlock)
-	out = strings.ReplaceAll(out, "{REPO}", snap.Repo)
-	out = strings.ReplaceAll(out, "{PR_NUMBER}", fmt.Sprintf("%d", snap.PRNumber))
-	out = strings.ReplaceAll(out, "{TITLE}", snap.Title)
-	out = strings.ReplaceAll(out, "{DESCRIPTION}", snap.Description)
-	out = strings.ReplaceAll(out, "{BASE_SHA}", snap.BaseSHA)
-	out = strings.ReplaceAll(out, "{HEAD_SHA}", snap.HeadSHA)
-	out = strings.ReplaceAll(out, "{CI_SUMMARY}", snap.CISummary)
-	out = strings.ReplaceAll(out, "{TEST_RESULTS}", snap.TestResults)
-	out = strings.ReplaceAll(out, "{FILE_LIST_WITH_STATS}", snap.FileListStats)
-	out = strings.ReplaceAll(out, "{DIFF_CHUNKS}", snap.DiffChunks)
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
 
-	return out
+var legacyPlaceholderRE = regexp.MustCompile(`\{([A-Z][A-Z_]*)\}`)
+
+func isLegacy(text string) bool {
+	return !strings.Contains(text, "{{")
+}
+
+// renderLegacy substitutes every known {NAME} in a single pass; unknown
+// names are left as written.
+func renderLegacy(text string, values map[string]string) string {
+	return legacyPlaceholderRE.ReplaceAllStringFunc(text, func(m string) string {
+		if value, ok := values[m[1:len(m)-1]]; ok {
+			return value
+		}
+		return m
+	})
+}
+
+var funcs = template.FuncMap{
+	"fence": fence,
+	"quote": quote,
+	"rules": renderRules,
+}
+
+func execute(name, text string, data any) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// fence wraps untrusted text in a code fence longer than any run of
+// backticks inside it, so the text cannot close the fence early and pass
+// itself off as instructions.
+func fence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	marker := strings.Repeat("`", max(3, longest+1))
+	return marker + "\n" + strings.TrimRight(text, "\n") + "\n" + marker
+}
+
+// quote renders an untrusted single-line value, such as the PR title, as a
+// quoted string, so embedded newlines cannot start a new prompt line.
+func quote(text string) string {
+	return strconv.Quote(text)
 }
 
 func renderRules(rules []string) string {
@@ -66,10 +197,12 @@ func renderRules(rules []string) string {
 	return strings.TrimSpace(b.String())
 }
 
-func DefaultSchemaPath() string {
-	path := os.Getenv("PRQ_SCHEMA_PATH")
-	if path != "" {
-		return path
-	}
-	return filepath.Join("schemas", "review_plan.schema.json")
+// LoadSchema resolves the review plan JSON schema.
+func LoadSchema() (Asset, error) {
+	return Resolve(ReviewSchemaSpec)
+}
+
+// LoadFollowupSchema resolves the follow-up check JSON schema.
+func LoadFollowupSchema() (Asset, error) {
+	return Resolve(FollowupSchemaSpec)
 }
@@ -5,9 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/brianndofor/prq/internal/config"
@@ -21,19 +19,15 @@ func escapeForShell(s string) string {
 	return strings.ReplaceAll(s, "'", "'\\''")
 }
 
-// loadSchemaContent reads a JSON schema file and returns its content as a string
-func loadSchemaContent(schemaPath string) (string, error) {
-	content, err := os.ReadFile(schemaPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read schema file: %w", err)
-	}
+// compactSchema returns a JSON schema as a single-line string
+func compactSchema(schema []byte) string {
 	// Compact the JSON to remove whitespace (optional, helps with arg length)
 	var buf bytes.Buffer
-	if err := json.Compact(&buf, content); err != nil {
+	if err := json.Compact(&buf, schema); err != nil {
 		// If compacting fails, use the original content
-		return string(content), nil
+		return string(schema)
 	}
-	return buf.String(), nil
+	return buf.String()
 }
 
 // claudeResponse represents the wrapper response from the Claude CLI
@@ -62,8 +56,9 @@ func extractStructuredOutput(raw []byte) ([]byte, error) {
 }
 
 type Runner interface {
-	RunReview(ctx context.Context, prompt string, schemaPath string) (ReviewPlan, string, error)
-	HealthCheck(ctx context.Context, schemaPath string) error
+	RunReview(ctx context.Context, prompt string, schema []byte) (ReviewPlan, string, error)
+	RunFollowupCheck(ctx context.Context, prompt string, schema []byte) (FollowupCheck, string, error)
+	HealthCheck(ctx context.Context, schema []byte) error
 }
 
 type ClaudeRunner struct {
@@ -79,12 +74,47 @@ func NewClaudeRunner(cfg config.ProviderConfig) *ClaudeRunner {
 	return &ClaudeRunner{command: command, args: cfg.Args}
 }
 
-func (c *ClaudeRunner) RunReview(ctx context.Context, prompt string, schemaPath string) (ReviewPlan, string, error) {
-	// Load schema content - CLI expects JSON string, not file path
-	schemaContent, err := loadSchemaContent(schemaPath)
+// WithArgs returns r with extra arguments appended to its command line, for
+// runners that have one; other runners are returned unchanged.
+func WithArgs(r Runner, extra []string) Runner {
+	c, ok := r.(*ClaudeRunner)
+	if !ok || len(extra) == 0 {
+		return r
+	}
+	args := append(append([]string{}, c.args...), extra...)
+	return &ClaudeRunner{command: c.command, args: args}
+}
+
+func (c *ClaudeRunner) RunReview(ctx context.Context, prompt string, schema []byte) (ReviewPlan, string, error) {
+	structuredOutput, raw, err := c.runStructured(ctx, prompt, schema)
 	if err != nil {
-		return ReviewPlan{}, "", err
+		return ReviewPlan{}, raw, err
+	}
+	var plan ReviewPlan
+	if err := json.Unmarshal(structuredOutput, &plan); err != nil {
+		return ReviewPlan{}, raw, fmt.Errorf("failed to parse provider JSON: %w", err)
 	}
+	return plan, raw, nil
+}
+
+func (c *ClaudeRunner) RunFollowupCheck(ctx context.Context, prompt string, schema []byte) (FollowupCheck, string, error) {
+	structuredOutput, raw, err := c.runStructured(ctx, prompt, schema)
+	if err != nil {
+		return FollowupCheck{}, raw, err
+	}
+	var check FollowupCheck
+	if err := json.Unmarshal(structuredOutput, &check); err != nil {
+		return FollowupCheck{}, raw, fmt.Errorf("failed to parse provider JSON: %w", err)
+	}
+	return check, raw, nil
+}
+
+// runStructured sends prompt to the CLI with the given schema and returns the
+// schema-validated structured output. raw is the best available provider
+// output for error reporting.
+func (c *ClaudeRunner) runStructured(ctx context.Context, prompt string, schema []byte) (structured []byte, raw string, err error) {
+	// CLI expects JSON string, not file path
+	schemaContent := compactSchema(schema)
 
 	// Use exec.Command directly and pass prompt via stdin to avoid "argument list too long" error
 	// The Claude CLI accepts prompt from stdin when no prompt argument is provided
@@ -98,33 +128,26 @@ func (c *ClaudeRunner) RunReview(ctx context.Context, prompt string, schemaPath
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		return ReviewPlan{}, "", fmt.Errorf("provider failed: %w\n%s", err, stderr.String())
+		return nil, "", fmt.Errorf("provider failed: %w\n%s", err, stderr.String())
 	}
-	raw := stdout.String()
+	raw = stdout.String()
 	// Extract the structured_output from Claude's response wrapper
 	structuredOutput, err := extractStructuredOutput([]byte(raw))
 	if err != nil {
-		return ReviewPlan{}, raw, err
+		return nil, raw, err
 	}
-	if err := validateJSON(schemaPath, structuredOutput); err != nil {
-		return ReviewPlan{}, string(structuredOutput), err
+	if err := validateJSON(schema, structuredOutput); err != nil {
+		return nil, string(structuredOutput), err
 	}
-	var plan ReviewPlan
-	if err := json.Unmarshal(structuredOutput, &plan); err != nil {
-		return ReviewPlan{}, string(structuredOutput), fmt.Errorf("failed to parse provider JSON: %w", err)
-	}
-	return plan, string(structuredOutput), nil
+	return structuredOutput, string(structuredOutput), nil
 }
 
-func (c *ClaudeRunner) HealthCheck(ctx context.Context, schemaPath string) error {
+func (c *ClaudeRunner) HealthCheck(ctx context.Context, schema []byte) error {
 	minimal := `{"summary":"ok","risk_level":"low","decision":"comment","key_changes":[],"issues":[],"questions":[],"praise":[],"draft_review_body":""}`
 	_ = minimal
 
-	// Load schema content - CLI expects JSON string, not file path
-	schemaContent, err := loadSchemaContent(schemaPath)
-	if err != nil {
-		return err
-	}
+	// CLI expects JSON string, not file path
+	schemaContent := compactSchema(schema)
 
 	// Build the full command to run through shell (handles JSON quoting)
 	shellCmd := fmt.Sprintf("%s --print --output-format json --json-schema '%s' 'Return JSON matching schema.'",
@@ -156,18 +179,18 @@ func (c *ClaudeRunner) HealthCheck(ctx context.Context, schemaPath string) error
 		_ = minimal
 		return fmt.Errorf("provider health check failed to extract structured output: %w\nRaw output: %s", err, stdout.String())
 	}
-	if err := validateJSON(schemaPath, structuredOutput); err != nil {
+	if err := validateJSON(schema, structuredOutput); err != nil {
 		return fmt.Errorf("provider output failed schema validation: %w\nOutput: %s\nStderr: %s", err, string(structuredOutput), stderr.String())
 	}
 	return nil
 }
 
-func validateJSON(schemaPath string, data []byte) error {
-	abspath, err := filepath.Abs(schemaPath)
-	if err != nil {
-		return fmt.Errorf("failed to resolve schema path: %w", err)
+func validateJSON(schema []byte, data []byte) error {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", bytes.NewReader(schema)); err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
 	}
-	schema, err := jsonschema.Compile("file://" + abspath)
+	compiled, err := compiler.Compile("schema.json")
 	if err != nil {
 		return fmt.Errorf("failed to load schema: %w", err)
 	}
@@ -176,7 +199,7 @@ func validateJSON(schemaPath string, data []byte) error {
 	if err := json.Unmarshal(data, &v); err != nil {
 		return fmt.Errorf("invalid JSON: %w", err)
 	}
-	if err := schema.Validate(v); err != nil {
+	if err := compiled.Validate(v); err != nil {
 		return fmt.Errorf("provider output failed schema validation: %w", err)
 	}
 	return nil
@@ -5,20 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 type FakeRunner struct {
 	FixturePath string
+	// FollowupFixturePath defaults to followup.json next to FixturePath.
+	FollowupFixturePath string
 }
 
 func NewFakeRunner(path string) *FakeRunner {
-	return &FakeRunner{FixturePath: path}
+	return &FakeRunner{FixturePath: path, FollowupFixturePath: filepath.Join(filepath.Dir(path), "followup.json")}
 }
 
-func (f *FakeRunner) RunReview(ctx context.Context, prompt string, schemaPath string) (ReviewPlan, string, error) {
+func (f *FakeRunner) RunReview(ctx context.Context, prompt string, schema []byte) (ReviewPlan, string, error) {
 	_ = ctx
 	_ = prompt
-	_ = schemaPath
+	_ = schema
 	data, err := os.ReadFile(f.FixturePath)
 	if err != nil {
 		return ReviewPlan{}, "", fmt.Errorf("failed to read provider fixture: %w", err)
@@ -30,8 +33,23 @@ func (f *FakeRunner) RunReview(ctx context.Context, prompt string, schemaPath st
 	return plan, string(data), nil
 }
 
-func (f *FakeRunner) HealthCheck(ctx context.Context, schemaPath string) error {
+func (f *FakeRunner) RunFollowupCheck(ctx context.Context, prompt string, schema []byte) (FollowupCheck, string, error) {
 	_ = ctx
-	_ = schemaPath
+	_ = prompt
+	_ = schema
+	data, err := os.ReadFile(f.FollowupFixturePath)
+	if err != nil {
+		return FollowupCheck{}, "", fmt.Errorf("failed to read provider followup fixture: %w", err)
+	}
+	var check FollowupCheck
+	if err := json.Unmarshal(data, &check); err != nil {
+		return FollowupCheck{}, string(data), fmt.Errorf("invalid provider followup fixture: %w", err)
+	}
+	return check, string(data), nil
+}
+
+func (f *FakeRunner) HealthCheck(ctx context.Context, schema []byte) error {
+	_ = ctx
+	_ = schema
 	return nil
 }
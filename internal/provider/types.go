@@ -20,4 +20,25 @@ type Issue struct {
 	Message         string  `json:"message"`
 	SuggestionPatch string  `json:"suggestion_patch,omitempty"`
 	Confidence      float64 `json:"confidence,omitempty"`
+	// Tool names the static analyzer that reported the issue; it is empty
+	// for issues raised by the model.
+	Tool string `json:"tool,omitempty"`
+}
+
+// Follow-up verdict statuses, matching schemas/followup_check.schema.json.
+const (
+	FollowupAddressed          = "addressed"
+	FollowupPartiallyAddressed = "partially_addressed"
+	FollowupNotAddressed       = "not_addressed"
+)
+
+type FollowupCheck struct {
+	Verdicts []ThreadVerdict `json:"verdicts"`
+}
+
+type ThreadVerdict struct {
+	ThreadID       string `json:"thread_id"`
+	Status         string `json:"status"`
+	Rationale      string `json:"rationale"`
+	SuggestedReply string `json:"suggested_reply,omitempty"`
 }
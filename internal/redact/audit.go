@@ -0,0 +1,151 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholderRE matches the numbered placeholders of an Audit and the
+// anonymous marker of Redact.
+var placeholderRE = regexp.MustCompile(`\[SECRET_\d+\]|` + regexp.QuoteMeta(Redacted))
+
+// Audit redacts the parts of a prompt and records what was hidden, so the
+// redactions can be reported and reviewed. Each distinct secret gets a
+// stable numbered placeholder such as [SECRET_3]; the secrets themselves are
+// only held in memory, so model output can be checked against them.
+type Audit struct {
+	redactor *Redactor
+	enabled  bool
+	Findings []Finding
+	// secrets maps each redacted value to its placeholder.
+	secrets map[string]string
+}
+
+// NewAudit returns an Audit that redacts with r, only when enabled. A nil r
+// uses the built-in rules.
+func NewAudit(r *Redactor, enabled bool) *Audit {
+	return &Audit{redactor: r, enabled: enabled, secrets: map[string]string{}}
+}
+
+func (a *Audit) placeholder(secret string) string {
+	if p, ok := a.secrets[secret]; ok {
+		return p
+	}
+	p := fmt.Sprintf("[SECRET_%d]", len(a.secrets)+1)
+	a.secrets[secret] = p
+	return p
+}
+
+// Scrub replaces any secret this Audit redacted that reappears in text, such
+// as one reproduced by the model, with its placeholder. It returns the
+// scrubbed text and the number of replacements.
+func (a *Audit) Scrub(text string) (string, int) {
+	if len(a.secrets) == 0 || text == "" {
+		return text, 0
+	}
+	// Longer secrets first, so one containing another is replaced whole.
+	values := make([]string, 0, len(a.secrets))
+	for value := range a.secrets {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	count := 0
+	for _, value := range values {
+		if n := strings.Count(text, value); n > 0 {
+			count += n
+			text = strings.ReplaceAll(text, value, a.secrets[value])
+		}
+	}
+	return text, count
+}
+
+// Placeholders returns the distinct redaction placeholders in text, in order
+// of appearance.
+func Placeholders(text string) []string {
+	var found []string
+	seen := map[string]bool{}
+	for _, p := range placeholderRE.FindAllString(text, -1) {
+		if !seen[p] {
+			seen[p] = true
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// Redact redacts input, recording its findings under source.
+func (a *Audit) Redact(source string, input string) string {
+	if !a.enabled {
+		return input
+	}
+	output, findings := a.redactor.replace(input, a.placeholder)
+	for _, f := range findings {
+		f.Source = source
+		a.Findings = append(a.Findings, f)
+	}
+	return output
+}
+
+// RedactLines redacts each line of a list, such as rules, numbering findings
+// by list entry.
+func (a *Audit) RedactLines(source string, lines []string) []string {
+	if !a.enabled {
+		return lines
+	}
+	output := make([]string, 0, len(lines))
+	for i, line := range lines {
+		redacted, findings := a.redactor.replace(line, a.placeholder)
+		for _, f := range findings {
+			f.Source = source
+			f.Line = i + 1
+			a.Findings = append(a.Findings, f)
+		}
+		output = append(output, redacted)
+	}
+	return output
+}
+
+// RedactPromptBlock is the final pass over the rendered prompt, like
+// Redactor.RedactPromptBlock.
+func (a *Audit) RedactPromptBlock(input string) string {
+	return strings.ReplaceAll(a.Redact("prompt", input), "\u0000", "")
+}
+
+// RuleCount is the number of redactions made by one rule.
+type RuleCount struct {
+	Rule  string
+	Count int
+}
+
+// Counts tallies findings per rule, most frequent first.
+func Counts(findings []Finding) []RuleCount {
+	byRule := map[string]int{}
+	for _, f := range findings {
+		byRule[f.Rule]++
+	}
+	counts := make([]RuleCount, 0, len(byRule))
+	for rule, n := range byRule {
+		counts = append(counts, RuleCount{Rule: rule, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Rule < counts[j].Rule
+	})
+	return counts
+}
+
+// Summary is a one-line count such as "3 (github-token 2, jwt 1)".
+func Summary(findings []Finding) string {
+	if len(findings) == 0 {
+		return "none"
+	}
+	parts := []string{}
+	for _, c := range Counts(findings) {
+		parts = append(parts, fmt.Sprintf("%s %d", c.Rule, c.Count))
+	}
+	return fmt.Sprintf("%d (%s)", len(findings), strings.Join(parts, ", "))
+}
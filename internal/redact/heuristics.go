@@ -0,0 +1,90 @@
+package redact
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// entropyThreshold is the Shannon entropy, in bits per character, above
+	// which an unexplained base64-like string is treated as a secret.
+	entropyThreshold = 4.0
+	// keywordEntropyThreshold applies when a secret keyword precedes the
+	// string on the same line.
+	keywordEntropyThreshold = 3.0
+	// keywordWindow is how far before the string a keyword is looked for.
+	keywordWindow = 40
+)
+
+var (
+	// hashPrefixRE matches the labels of checksum formats written right
+	// before the value: go.sum "h1:", SRI and npm "sha512-", and digests
+	// such as "sha256:".
+	hashPrefixRE = regexp.MustCompile(`(?i)(\bh1:|\bsha(1|224|256|384|512)[-:]|\bmd5[-:])$`)
+	secretKeyRE  = regexp.MustCompile(`(?i)(secret|token|passw(or)?d|pwd|api[_-]?key|private[_-]?key|credential|bearer|signature|salt)`)
+	pureHexRE    = regexp.MustCompile(`^[A-Fa-f0-9]+$`)
+)
+
+// lockfiles hold checksums and resolved URLs rather than secrets.
+var lockfiles = map[string]bool{
+	"go.sum":              true,
+	"go.work.sum":         true,
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"Cargo.lock":          true,
+	"poetry.lock":         true,
+	"composer.lock":       true,
+	"Gemfile.lock":        true,
+	"flake.lock":          true,
+}
+
+// likelySecret decides whether the high-entropy candidate input[start:end]
+// should be redacted, using the surrounding text: the file it appears in,
+// a checksum label before it, and secret keywords on the same line.
+func likelySecret(input string, start, end int) bool {
+	candidate := input[start:end]
+	if lockfiles[path.Base(fileAt(input, start))] {
+		return false
+	}
+	before := input[max(lineStart(input, start), start-keywordWindow):start]
+	if hashPrefixRE.MatchString(before) {
+		return false
+	}
+	ent := entropy(candidate)
+	if secretKeyRE.MatchString(before) {
+		return ent >= keywordEntropyThreshold
+	}
+	// Hex runs are git object IDs, digests, or dashless UUIDs; hex secrets
+	// are caught through keywords above.
+	if pureHexRE.MatchString(candidate) {
+		return false
+	}
+	// Random base64 of this length almost always mixes digits and both
+	// cases; paths and identifiers usually do not.
+	if !hasDigitAndMixedCase(candidate) {
+		return false
+	}
+	return ent >= entropyThreshold
+}
+
+func lineStart(input string, offset int) int {
+	return strings.LastIndexByte(input[:offset], '\n') + 1
+}
+
+func hasDigitAndMixedCase(s string) bool {
+	var digit, upper, lower bool
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		}
+	}
+	return digit && upper && lower
+}
@@ -1,6 +1,7 @@
 package redact
 
 import (
+	"fmt"
 	"math"
 	"regexp"
 	"strings"
@@ -20,101 +21,120 @@ var (
 	hexLike      = regexp.MustCompile(`[A-Fa-f0-9]{32,}`)
 )
 
-func Redact(input string) string {
-	if input == "" {
-		return input
-	}
-	output := input
-	output = privateKey.ReplaceAllString(output, Redacted)
-	output = awsAccessKey.ReplaceAllString(output, Redacted)
-	output = awsSecretKey.ReplaceAllString(output, Redacted)
-	output = ghToken.ReplaceAllString(output, Redacted)
-	output = jwtToken.ReplaceAllString(output, Redacted)
-	output = genericToken.ReplaceAllString(output, Redacted)
-	output = urlParams.ReplaceAllString(output, "${1}"+Redacted)
-	output = redactHighEntropy(output)
-	return output
+// rule is one kind of secret. span picks the redacted part of a match, as
+// byte offsets into the input, or ok=false to keep the match.
+type rule struct {
+	name string
+	re   *regexp.Regexp
+	span func(input string, m []int) (start, end int, ok bool)
 }
 
-func redactHighEntropy(input string) string {
-	output := input
-	output = replaceIfHighEntropy(output, base64Like)
-	output = replaceIfHighEntropy(output, hexLike)
-	return output
-}
+func wholeMatch(_ string, m []int) (int, int, bool) { return m[0], m[1], true }
 
-func replaceIfHighEntropy(input string, re *regexp.Regexp) string {
-	return re.ReplaceAllStringFunc(input, func(match string) string {
-		if entropy(match) >= 4.0 {
-			return Redacted
-		}
-		return match
-	})
+// afterGroup1 keeps the first group, e.g. a URL parameter name, and redacts
+// the rest of the match.
+func afterGroup1(_ string, m []int) (int, int, bool) { return m[3], m[1], true }
+
+func highEntropy(input string, m []int) (int, int, bool) {
+	return m[0], m[1], likelySecret(input, m[0], m[1])
 }
 
-func entropy(s string) float64 {
-	if s == "" {
-		return 0
-	}
-	counts := make(map[rune]int)
-	for _, r := range s {
-		counts[r]++
+// Rules are applied in order: the built-in rules, custom rules, then the
+// entropy rules. A match overlapping an earlier redaction is skipped.
+var (
+	builtinRules = []rule{
+		{"private-key", privateKey, wholeMatch},
+		{"aws-access-key", awsAccessKey, wholeMatch},
+		{"aws-secret-key", awsSecretKey, wholeMatch},
+		{"github-token", ghToken, wholeMatch},
+		{"jwt", jwtToken, wholeMatch},
+		{"generic-token", genericToken, wholeMatch},
+		{"url-param", urlParams, afterGroup1},
 	}
-	length := float64(len([]rune(s)))
-	var ent float64
-	for _, count := range counts {
-		p := float64(count) / length
-		ent -= p * log2(p)
+	entropyRules = []rule{
+		{"high-entropy-base64", base64Like, highEntropy},
+		{"high-entropy-hex", hexLike, highEntropy},
 	}
-	return ent
-}
+)
 
-func log2(n float64) float64 {
-	return (log(n) / log(2))
+// Finding describes one redaction without revealing the secret.
+type Finding struct {
+	Rule string `json:"rule"`
+	// Source names the redacted text, e.g. "diff" or "title"; set by Audit.
+	Source string `json:"source,omitempty"`
+	// File is the diff file the secret was in, when the text has file
+	// headers.
+	File string `json:"file,omitempty"`
+	// Line is 1-based within the redacted text.
+	Line   int `json:"line"`
+	Length int `json:"length"`
+	// Hash is a short SHA-256 of the secret, for matching findings across
+	// runs.
+	Hash string `json:"hash"`
+	// Placeholder is the text that replaced the secret.
+	Placeholder string `json:"placeholder"`
+	// Phrase is the deny-list entry a DenyListRule finding matched.
+	Phrase string `json:"phrase,omitempty"`
 }
 
-func log(n float64) float64 {
-	return mathLog(n)
-}
+// DenyListRule is the Rule of findings for deny-list phrases.
+const DenyListRule = "deny-list"
 
-// mathLog is separated for test stubbing if needed.
-var mathLog = func(n float64) float64 {
-	return math.Log(n)
-}
-
-func RedactAll(inputs ...string) []string {
-	redacted := make([]string, 0, len(inputs))
-	for _, input := range inputs {
-		redacted = append(redacted, Redact(input))
+// Location is a human-readable position such as "diff auth.go, line 12".
+func (f Finding) Location() string {
+	where := f.Source
+	if f.File != "" {
+		where = strings.TrimSpace(where + " " + f.File)
+	}
+	if where == "" {
+		return fmt.Sprintf("line %d", f.Line)
 	}
-	return redacted
+	return fmt.Sprintf("%s, line %d", where, f.Line)
 }
 
-func RedactLines(lines []string) []string {
-	output := make([]string, 0, len(lines))
-	for _, line := range lines {
-		output = append(output, Redact(line))
-	}
-	return output
+func Redact(input string) string {
+	return defaultRedactor.Redact(input)
 }
 
-func RedactOptional(input string, enabled bool) string {
-	if !enabled {
-		return input
-	}
-	return Redact(input)
+// RedactWithFindings redacts input with the built-in rules and reports each
+// redaction.
+func RedactWithFindings(input string) (string, []Finding) {
+	return defaultRedactor.RedactWithFindings(input)
 }
 
-func RedactRuleList(rules []string, enabled bool) []string {
-	if !enabled {
-		return rules
+var diffHeaderRE = regexp.MustCompile(`^diff --git a/\S+ b/(\S+)$`)
+
+// fileAt returns the path from the last diff file header ("File: path" or
+// "diff --git a/x b/path") before offset, scanning back line by line.
+func fileAt(input string, offset int) string {
+	end := strings.LastIndexByte(input[:offset], '\n')
+	for end >= 0 {
+		start := strings.LastIndexByte(input[:end], '\n') + 1
+		line := input[start:end]
+		if path, ok := strings.CutPrefix(line, "File: "); ok {
+			return strings.TrimSpace(path)
+		}
+		if m := diffHeaderRE.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+		end = start - 1
 	}
-	return RedactLines(rules)
+	return ""
 }
 
-func RedactPromptBlock(input string, enabled bool) string {
-	if !enabled {
-		return input
+func entropy(s string) float64 {
+	if s == "" {
+		return 0
 	}
-	return strings.ReplaceAll(Redact(input), "\u0000", "")
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	length := float64(len([]rune(s)))
+	var ent float64
+	for _, count := range counts {
+		p := float64(count) / length
+		ent -= p * math.Log2(p)
+	}
+	return ent
 }
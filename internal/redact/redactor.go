@@ -0,0 +1,240 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule is a named regular expression whose matches are redacted. If the
+// pattern has a group named "secret", only that group is redacted.
+type Rule struct {
+	Name    string
+	Pattern string
+}
+
+// Options extends the built-in rules.
+type Options struct {
+	Rules []Rule
+	// Allowlist holds patterns for known-safe strings. A candidate secret
+	// inside an allowlist match is never redacted, whichever rule found it.
+	Allowlist []string
+	// DenyList holds phrases, such as internal codenames, that Scan reports
+	// in outbound text. They are matched case-insensitively as whole words
+	// and are never redacted from prompts.
+	DenyList []string
+}
+
+// Redactor applies a compiled rule set. The zero value and nil use the
+// built-in rules only.
+type Redactor struct {
+	rules []rule
+	allow []*regexp.Regexp
+	deny  []denyPhrase
+}
+
+type denyPhrase struct {
+	phrase string
+	re     *regexp.Regexp
+}
+
+var defaultRedactor = &Redactor{}
+
+// New compiles the built-in rules plus opts, reporting the first invalid
+// pattern.
+func New(opts Options) (*Redactor, error) {
+	r := &Redactor{rules: append([]rule{}, builtinRules...)}
+	names := map[string]bool{}
+	for _, b := range append(append([]rule{}, builtinRules...), entropyRules...) {
+		names[b.name] = true
+	}
+	for _, custom := range opts.Rules {
+		name := strings.TrimSpace(custom.Name)
+		if name == "" {
+			return nil, fmt.Errorf("redaction rule %q: name is required", custom.Pattern)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("redaction rule %q: name already in use", name)
+		}
+		names[name] = true
+		re, err := regexp.Compile(custom.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule %q: %w", name, err)
+		}
+		span := wholeMatch
+		if idx := re.SubexpIndex("secret"); idx > 0 {
+			span = groupSpan(idx)
+		}
+		r.rules = append(r.rules, rule{name: name, re: re, span: span})
+	}
+	r.rules = append(r.rules, entropyRules...)
+	for _, pattern := range opts.Allowlist {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction allowlist pattern %q: %w", pattern, err)
+		}
+		r.allow = append(r.allow, re)
+	}
+	for _, phrase := range opts.DenyList {
+		phrase = strings.TrimSpace(phrase)
+		if phrase == "" {
+			continue
+		}
+		r.deny = append(r.deny, denyPhrase{phrase: phrase, re: denyPattern(phrase)})
+	}
+	return r, nil
+}
+
+// denyPattern matches phrase case-insensitively, requiring a word boundary
+// at each end that starts or ends with a word character, so "tengu" does
+// not match inside "stenguard".
+func denyPattern(phrase string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(phrase)
+	if isWordByte(phrase[0]) {
+		pattern = `\b` + pattern
+	}
+	if isWordByte(phrase[len(phrase)-1]) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func groupSpan(idx int) func(string, []int) (int, int, bool) {
+	return func(_ string, m []int) (int, int, bool) {
+		return m[2*idx], m[2*idx+1], m[2*idx] >= 0
+	}
+}
+
+func (r *Redactor) ruleSet() []rule {
+	if r == nil || r.rules == nil {
+		return append(append([]rule{}, builtinRules...), entropyRules...)
+	}
+	return r.rules
+}
+
+func (r *Redactor) Redact(input string) string {
+	output, _ := r.RedactWithFindings(input)
+	return output
+}
+
+// RedactOptional redacts input only when enabled.
+func (r *Redactor) RedactOptional(input string, enabled bool) string {
+	if !enabled {
+		return input
+	}
+	return r.Redact(input)
+}
+
+// RedactPromptBlock is the final pass over a rendered prompt.
+func (r *Redactor) RedactPromptBlock(input string, enabled bool) string {
+	if !enabled {
+		return input
+	}
+	return strings.ReplaceAll(r.Redact(input), "\u0000", "")
+}
+
+// RedactWithFindings redacts input and reports each redaction.
+func (r *Redactor) RedactWithFindings(input string) (string, []Finding) {
+	return r.replace(input, func(string) string { return Redacted })
+}
+
+// Scan reports the secrets and deny-list phrases in outbound text, such as a
+// review about to be posted, without changing it. Findings are ordered by
+// line; deny-list findings have Rule "deny-list" and name the Phrase.
+func (r *Redactor) Scan(text string) []Finding {
+	_, findings := r.RedactWithFindings(text)
+	for i := range findings {
+		findings[i].Placeholder = ""
+	}
+	if r == nil {
+		return findings
+	}
+	for _, d := range r.deny {
+		for _, m := range d.re.FindAllStringIndex(text, -1) {
+			sum := sha256.Sum256([]byte(text[m[0]:m[1]]))
+			findings = append(findings, Finding{
+				Rule:   DenyListRule,
+				Phrase: d.phrase,
+				File:   fileAt(text, m[0]),
+				Line:   strings.Count(text[:m[0]], "\n") + 1,
+				Length: m[1] - m[0],
+				Hash:   hex.EncodeToString(sum[:])[:12],
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	return findings
+}
+
+// replace redacts input, substituting placeholder(secret) for each secret.
+func (r *Redactor) replace(input string, placeholder func(secret string) string) (string, []Finding) {
+	if input == "" {
+		return input, nil
+	}
+	type redaction struct {
+		start, end int
+		rule       string
+	}
+	var allowed [][]int
+	if r != nil {
+		for _, re := range r.allow {
+			allowed = append(allowed, re.FindAllStringIndex(input, -1)...)
+		}
+	}
+	var spans []redaction
+	skip := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && s.start < end {
+				return true
+			}
+		}
+		for _, a := range allowed {
+			if a[0] <= start && end <= a[1] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rl := range r.ruleSet() {
+		for _, m := range rl.re.FindAllStringSubmatchIndex(input, -1) {
+			start, end, ok := rl.span(input, m)
+			if !ok || start >= end || skip(start, end) {
+				continue
+			}
+			spans = append(spans, redaction{start: start, end: end, rule: rl.name})
+		}
+	}
+	if len(spans) == 0 {
+		return input, nil
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	findings := make([]Finding, 0, len(spans))
+	last := 0
+	for _, s := range spans {
+		secret := input[s.start:s.end]
+		marker := placeholder(secret)
+		b.WriteString(input[last:s.start])
+		b.WriteString(marker)
+		last = s.end
+		sum := sha256.Sum256([]byte(secret))
+		findings = append(findings, Finding{
+			Rule:        s.rule,
+			File:        fileAt(input, s.start),
+			Line:        strings.Count(input[:s.start], "\n") + 1,
+			Length:      s.end - s.start,
+			Hash:        hex.EncodeToString(sum[:])[:12],
+			Placeholder: marker,
+		})
+	}
+	b.WriteString(input[last:])
+	return b.String(), findings
+}
@@ -0,0 +1,394 @@
+// Package related finds the definitions and call sites of exported Go
+// identifiers touched by a diff, using only go/parser on a local checkout.
+package related
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/brianndofor/prq/internal/diff"
+)
+
+// maxDefinitionLines caps how much of a single declaration is quoted.
+const maxDefinitionLines = 60
+
+// Symbol is an exported identifier declared in a changed Go file.
+type Symbol struct {
+	Name string
+	// Receiver is the receiver type name for methods, empty otherwise.
+	Receiver string
+	Kind     string
+	File     string
+	Line     int
+	EndLine  int
+	// Definition is the declaration source, truncated to maxDefinitionLines.
+	Definition string
+	// ImportPath is the import path of the declaring package, empty when the
+	// checkout has no go.mod.
+	ImportPath string
+	dir        string
+}
+
+// Reference is a use of a Symbol outside its declaration.
+type Reference struct {
+	File string
+	Line int
+	Text string
+}
+
+// Options bounds the search.
+type Options struct {
+	// MaxReferences limits references collected per symbol.
+	MaxReferences int
+	// Ignore holds globs (as in diff.ignore) for files to skip.
+	Ignore []string
+}
+
+// ChangedSymbols parses the changed, non-test Go files under root and returns
+// the exported top-level identifiers whose declarations overlap the changed
+// line ranges. changes maps slash-separated paths relative to root to the
+// new-side ranges touched by the diff.
+func ChangedSymbols(root string, changes map[string][]diff.LineRange) ([]Symbol, error) {
+	modules := map[string]string{}
+	paths := make([]string, 0, len(changes))
+	for p := range changes {
+		if strings.HasSuffix(p, ".go") && !strings.HasSuffix(p, "_test.go") {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var symbols []Symbol
+	for _, rel := range paths {
+		src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", rel, err)
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, rel, src, parser.ParseComments)
+		if err != nil {
+			// A file that does not parse has no reliable declarations.
+			continue
+		}
+		dir := path.Dir(rel)
+		importPath := packageImportPath(root, dir, modules)
+		for _, sym := range declaredSymbols(fset, file, src) {
+			if !overlapsAny(sym.Line, sym.EndLine, changes[rel]) {
+				continue
+			}
+			sym.File = rel
+			sym.ImportPath = importPath
+			sym.dir = dir
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols, nil
+}
+
+func declaredSymbols(fset *token.FileSet, file *ast.File, src []byte) []Symbol {
+	var out []Symbol
+	add := func(name, receiver, kind string, node ast.Node) {
+		if !ast.IsExported(name) {
+			return
+		}
+		start := fset.Position(node.Pos())
+		end := fset.Position(node.End())
+		out = append(out, Symbol{
+			Name:       name,
+			Receiver:   receiver,
+			Kind:       kind,
+			Line:       start.Line,
+			EndLine:    end.Line,
+			Definition: clipLines(string(src[start.Offset:end.Offset]), maxDefinitionLines),
+		})
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				add(d.Name.Name, receiverName(d.Recv.List[0].Type), "method", d)
+			} else {
+				add(d.Name.Name, "", "func", d)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name.Name, "", "type", s)
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range s.Names {
+						add(name.Name, "", kind, s)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// FindReferences walks the Go files under root and collects up to
+// opts.MaxReferences uses of each symbol. Without type information, matches
+// are by name: bare identifiers in the declaring package, qualified
+// identifiers in packages that import it, and any selector for methods.
+func FindReferences(root string, symbols []Symbol, opts Options) (map[string][]Reference, error) {
+	refs := map[string][]Reference{}
+	if len(symbols) == 0 {
+		return refs, nil
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(rel, ".go") || ignored(rel, opts.Ignore) || allFull(symbols, refs, opts.MaxReferences) {
+			return nil
+		}
+		return scanFile(root, rel, symbols, refs, opts.MaxReferences)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+func scanFile(root, rel string, symbols []Symbol, refs map[string][]Reference, limit int) error {
+	src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", rel, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	aliases := importAliases(file)
+	dir := path.Dir(rel)
+	lines := strings.Split(string(src), "\n")
+
+	record := func(sym Symbol, pos token.Pos) {
+		key := Key(sym)
+		if limit > 0 && len(refs[key]) >= limit {
+			return
+		}
+		position := fset.Position(pos)
+		if rel == sym.File && position.Line >= sym.Line && position.Line <= sym.EndLine {
+			return
+		}
+		text := ""
+		if position.Line-1 < len(lines) {
+			text = strings.TrimSpace(lines[position.Line-1])
+		}
+		refs[key] = append(refs[key], Reference{File: rel, Line: position.Line, Text: text})
+	}
+
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			for _, sym := range symbols {
+				if node.Sel.Name != sym.Name {
+					continue
+				}
+				if sym.Receiver != "" {
+					record(sym, node.Sel.Pos())
+					continue
+				}
+				if pkg, ok := node.X.(*ast.Ident); ok && sym.ImportPath != "" && aliases[pkg.Name] == sym.ImportPath {
+					record(sym, node.Sel.Pos())
+				}
+			}
+			// Sel is a field or qualified name, never a bare identifier.
+			ast.Inspect(node.X, visit)
+			return false
+		case *ast.Ident:
+			matchBare(node, dir, symbols, record)
+		}
+		return true
+	}
+	ast.Inspect(file, visit)
+	return nil
+}
+
+func matchBare(ident *ast.Ident, dir string, symbols []Symbol, record func(Symbol, token.Pos)) {
+	for _, sym := range symbols {
+		if sym.Receiver == "" && sym.dir == dir && ident.Name == sym.Name {
+			record(sym, ident.Pos())
+		}
+	}
+}
+
+// importAliases maps the name a file uses for each import to its path.
+func importAliases(file *ast.File) map[string]string {
+	aliases := map[string]string{}
+	for _, spec := range file.Imports {
+		importPath := strings.Trim(spec.Path.Value, `"`)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		aliases[name] = importPath
+	}
+	return aliases
+}
+
+// Key identifies a symbol in the reference map.
+func Key(sym Symbol) string {
+	if sym.Receiver != "" {
+		return sym.File + ":" + sym.Receiver + "." + sym.Name
+	}
+	return sym.File + ":" + sym.Name
+}
+
+// Render formats definitions and references as a prompt section.
+func Render(symbols []Symbol, refs map[string][]Reference) string {
+	if len(symbols) == 0 {
+		return "None"
+	}
+	var b strings.Builder
+	for i, sym := range symbols {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		name := sym.Name
+		if sym.Receiver != "" {
+			name = sym.Receiver + "." + sym.Name
+		}
+		fmt.Fprintf(&b, "%s %s (%s:%d)\n", sym.Kind, name, sym.File, sym.Line)
+		b.WriteString(sym.Definition)
+		b.WriteString("\n")
+		found := refs[Key(sym)]
+		if len(found) == 0 {
+			b.WriteString("References: none found\n")
+			continue
+		}
+		b.WriteString("References:\n")
+		for _, ref := range found {
+			fmt.Fprintf(&b, "  %s:%d: %s\n", ref.File, ref.Line, ref.Text)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// packageImportPath returns the import path of the package in dir, a
+// slash-separated path relative to root, using the nearest go.mod at or
+// above it so nested modules resolve correctly. It returns "" when no go.mod
+// is found. modules caches module paths by directory.
+func packageImportPath(root, dir string, modules map[string]string) string {
+	for modDir := dir; ; modDir = path.Dir(modDir) {
+		modulePath, ok := modules[modDir]
+		if !ok {
+			modulePath = readModulePath(filepath.Join(root, filepath.FromSlash(modDir)))
+			modules[modDir] = modulePath
+		}
+		if modulePath != "" {
+			if modDir == dir {
+				return modulePath
+			}
+			if modDir == "." {
+				return modulePath + "/" + dir
+			}
+			return modulePath + "/" + strings.TrimPrefix(dir, modDir+"/")
+		}
+		if modDir == "." {
+			return ""
+		}
+	}
+}
+
+func readModulePath(dir string) string {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return ""
+}
+
+func overlapsAny(start, end int, ranges []diff.LineRange) bool {
+	for _, r := range ranges {
+		if start <= r.End && r.Start <= end {
+			return true
+		}
+	}
+	return false
+}
+
+func clipLines(text string, limit int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= limit {
+		return text
+	}
+	return strings.Join(lines[:limit], "\n") + "\n// ... truncated"
+}
+
+func skipDir(name string) bool {
+	return name == "vendor" || name == "testdata" || name == "node_modules" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+func ignored(rel string, globs []string) bool {
+	for _, glob := range globs {
+		if match, err := filepath.Match(glob, rel); err == nil && match {
+			return true
+		}
+	}
+	return false
+}
+
+func allFull(symbols []Symbol, refs map[string][]Reference, limit int) bool {
+	if limit <= 0 {
+		return false
+	}
+	for _, sym := range symbols {
+		if len(refs[Key(sym)]) < limit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package related
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brianndofor/prq/internal/diff"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func TestChangedSymbolsAndReferences(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.24\n",
+		"auth/auth.go": `package auth
+
+// Login authenticates user.
+func Login(user string, token string) error {
+	return check(user)
+}
+
+func check(user string) error {
+	return nil
+}
+
+type Session struct{}
+
+func (s *Session) Refresh() error {
+	return Login("", "")
+}
+`,
+		"cmd/main.go": `package main
+
+import (
+	"example.com/app/auth"
+	authz "example.com/app/auth"
+)
+
+func main() {
+	_ = auth.Login("a", "b")
+	_ = authz.Login("c", "d")
+	var s auth.Session
+	_ = s.Refresh()
+}
+`,
+		"vendor/dep/dep.go": "package dep\n\nfunc x() { auth.Login(\"\", \"\") }\n",
+	})
+
+	changes := map[string][]diff.LineRange{
+		"auth/auth.go":      {{Start: 4, End: 4}, {Start: 15, End: 15}},
+		"auth/auth_test.go": {{Start: 1, End: 1}},
+	}
+	symbols, err := ChangedSymbols(root, changes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(symbols) != 2 || symbols[0].Name != "Login" || symbols[1].Receiver != "Session" {
+		t.Fatalf("unexpected symbols: %+v", symbols)
+	}
+	if symbols[0].ImportPath != "example.com/app/auth" || !strings.HasPrefix(symbols[0].Definition, "func Login(") {
+		t.Fatalf("unexpected Login symbol: %+v", symbols[0])
+	}
+
+	refs, err := FindReferences(root, symbols, Options{MaxReferences: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	login := refs[Key(symbols[0])]
+	if len(login) != 2 {
+		t.Fatalf("expected references capped at 2, got %+v", login)
+	}
+	for _, ref := range login {
+		if strings.HasPrefix(ref.File, "vendor/") {
+			t.Fatalf("expected vendor to be skipped: %+v", ref)
+		}
+	}
+	refresh := refs[Key(symbols[1])]
+	if len(refresh) != 1 || refresh[0].File != "cmd/main.go" || refresh[0].Line != 12 {
+		t.Fatalf("unexpected Refresh references: %+v", refresh)
+	}
+
+	out := Render(symbols, refs)
+	for _, want := range []string{"func Login (auth/auth.go:4)", "method Session.Refresh (auth/auth.go:14)", "  cmd/main.go:12: _ = s.Refresh()"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderNone(t *testing.T) {
+	if got := Render(nil, nil); got != "None" {
+		t.Fatalf("expected None, got %q", got)
+	}
+}
+
+func TestChangedSymbolsNestedModule(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"go.mod":                     "module example.com/app\n",
+		"tools/gen/go.mod":           "module example.com/gen\n",
+		"tools/gen/render/render.go": "package render\n\nfunc Render() {}\n",
+		"tools/gen/main.go":          "package main\n\nfunc Main() {}\n",
+		"web/web.go":                 "package web\n\nfunc Serve() {}\n",
+	})
+	changes := map[string][]diff.LineRange{
+		"tools/gen/render/render.go": {{Start: 3, End: 3}},
+		"tools/gen/main.go":          {{Start: 3, End: 3}},
+		"web/web.go":                 {{Start: 3, End: 3}},
+	}
+	symbols, err := ChangedSymbols(root, changes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := map[string]string{}
+	for _, sym := range symbols {
+		got[sym.Name] = sym.ImportPath
+	}
+	want := map[string]string{"Main": "example.com/gen", "Render": "example.com/gen/render", "Serve": "example.com/app/web"}
+	for name, path := range want {
+		if got[name] != path {
+			t.Fatalf("expected %s in %q, got %v", name, path, got)
+		}
+	}
+}
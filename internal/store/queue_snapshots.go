@@ -0,0 +1,155 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// queueSnapshotsKept bounds how many snapshots are retained per scope; only
+// the latest is needed to compute deltas, the rest is history for debugging.
+const queueSnapshotsKept = 20
+
+type QueueSnapshot struct {
+	ID      int64
+	Scope   string
+	TakenAt time.Time
+	Items   []QueueSnapshotItem
+}
+
+type QueueSnapshotItem struct {
+	PRID    string
+	Repo    string
+	Number  int
+	Title   string
+	HeadSHA string
+	Checks  string
+}
+
+// LatestQueueSnapshot returns the most recent snapshot recorded for scope, or
+// sql.ErrNoRows if the queue has never been fetched with that scope.
+func (s *Store) LatestQueueSnapshot(scope string) (QueueSnapshot, error) {
+	var snap QueueSnapshot
+	err := s.db.QueryRow(`
+		SELECT id, scope, taken_at
+		FROM queue_snapshots
+		WHERE scope = ?
+		ORDER BY id DESC
+		LIMIT 1
+	`, scope).Scan(&snap.ID, &snap.Scope, &snap.TakenAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return QueueSnapshot{}, err
+		}
+		return QueueSnapshot{}, fmt.Errorf("failed to read queue snapshot: %w", err)
+	}
+	rows, err := s.db.Query(`
+		SELECT pr_id, repo, number, title, head_sha, checks
+		FROM queue_snapshot_items
+		WHERE snapshot_id = ?
+		ORDER BY rowid
+	`, snap.ID)
+	if err != nil {
+		return QueueSnapshot{}, fmt.Errorf("failed to read queue snapshot items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item QueueSnapshotItem
+		if err := rows.Scan(&item.PRID, &item.Repo, &item.Number, &item.Title, &item.HeadSHA, &item.Checks); err != nil {
+			return QueueSnapshot{}, fmt.Errorf("failed to read queue snapshot item: %w", err)
+		}
+		snap.Items = append(snap.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return QueueSnapshot{}, fmt.Errorf("failed to read queue snapshot items: %w", err)
+	}
+	return snap, nil
+}
+
+// RecordQueueSnapshot stores the PRs seen by one queue fetch and prunes old
+// snapshots for the same scope.
+func (s *Store) RecordQueueSnapshot(scope string, items []QueueSnapshotItem) (int64, error) {
+	var id int64
+	err := s.InTx(func(tx *Store) error {
+		res, err := tx.db.Exec(`
+			INSERT INTO queue_snapshots (scope, taken_at)
+			VALUES (?, datetime('now'))
+		`, scope)
+		if err != nil {
+			return fmt.Errorf("failed to record queue snapshot: %w", err)
+		}
+		id, err = res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to record queue snapshot: %w", err)
+		}
+		for _, item := range items {
+			_, err := tx.db.Exec(`
+				INSERT OR REPLACE INTO queue_snapshot_items (snapshot_id, pr_id, repo, number, title, head_sha, checks)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+			`, id, item.PRID, item.Repo, item.Number, item.Title, item.HeadSHA, item.Checks)
+			if err != nil {
+				return fmt.Errorf("failed to record queue snapshot item: %w", err)
+			}
+		}
+		return tx.pruneQueueSnapshots(scope)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *Store) pruneQueueSnapshots(scope string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM queue_snapshot_items
+		WHERE snapshot_id IN (
+			SELECT id FROM queue_snapshots
+			WHERE scope = ?
+			ORDER BY id DESC
+			LIMIT -1 OFFSET ?
+		)
+	`, scope, queueSnapshotsKept)
+	if err != nil {
+		return fmt.Errorf("failed to prune queue snapshots: %w", err)
+	}
+	_, err = s.db.Exec(`
+		DELETE FROM queue_snapshots
+		WHERE id IN (
+			SELECT id FROM queue_snapshots
+			WHERE scope = ?
+			ORDER BY id DESC
+			LIMIT -1 OFFSET ?
+		)
+	`, scope, queueSnapshotsKept)
+	if err != nil {
+		return fmt.Errorf("failed to prune queue snapshots: %w", err)
+	}
+	return nil
+}
+
+// TrackedRepos returns the repos that still have PRs in the latest snapshot of
+// any scope or a saved draft review.
+func (s *Store) TrackedRepos() (map[string]bool, error) {
+	rows, err := s.db.Query(`
+		SELECT repo FROM queue_snapshot_items
+		WHERE snapshot_id IN (SELECT MAX(id) FROM queue_snapshots GROUP BY scope)
+		UNION
+		SELECT p.repo FROM prs p JOIN draft_reviews d ON d.pr_id = p.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tracked repos: %w", err)
+	}
+	defer rows.Close()
+	repos := map[string]bool{}
+	for rows.Next() {
+		var repo string
+		if err := rows.Scan(&repo); err != nil {
+			return nil, fmt.Errorf("failed to read tracked repo: %w", err)
+		}
+		repos[repo] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tracked repos: %w", err)
+	}
+	return repos, nil
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RepoConfig is the repo config file fetched from a repo at one commit. An
+// empty Path records that the commit has no config file.
+type RepoConfig struct {
+	Repo      string
+	SHA       string
+	Path      string
+	Content   string
+	FetchedAt time.Time
+}
+
+// GetRepoConfig returns the cached config for repo at sha, or sql.ErrNoRows
+// if it has not been fetched.
+func (s *Store) GetRepoConfig(repo, sha string) (RepoConfig, error) {
+	var rc RepoConfig
+	err := s.db.QueryRow(`
+		SELECT repo, sha, path, content, fetched_at
+		FROM repo_configs
+		WHERE repo = ? AND sha = ?
+	`, repo, sha).Scan(&rc.Repo, &rc.SHA, &rc.Path, &rc.Content, &rc.FetchedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return RepoConfig{}, err
+		}
+		return RepoConfig{}, fmt.Errorf("failed to read repo config: %w", err)
+	}
+	return rc, nil
+}
+
+// PutRepoConfig caches the config fetched for repo at sha. Contents at a
+// commit never change, so entries are only replaced, never expired.
+func (s *Store) PutRepoConfig(repo, sha, path, content string) error {
+	if repo == "" || sha == "" {
+		return fmt.Errorf("repo and sha are required")
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO repo_configs (repo, sha, path, content, fetched_at)
+		VALUES (?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(repo, sha) DO UPDATE SET
+			path = excluded.path,
+			content = excluded.content,
+			fetched_at = excluded.fetched_at
+	`, repo, sha, path, content)
+	if err != nil {
+		return fmt.Errorf("failed to cache repo config: %w", err)
+	}
+	return nil
+}
@@ -3,28 +3,85 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 
 	_ "modernc.org/sqlite"
 )
 
+// busyTimeoutMillis is how long a connection waits on a lock held by another
+// prq process before giving up with "database is locked".
+const busyTimeoutMillis = 5000
+
+// queryer is the subset of *sql.DB and *sql.Tx used by Store methods, so the
+// same methods work inside and outside a transaction.
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 type Store struct {
-	db *sql.DB
+	conn *sql.DB
+	db   queryer
+	inTx bool
 }
 
 func Open(path string) (*Store, error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create store dir: %w", err)
 	}
-	db, err := sql.Open("sqlite", path)
+	db, err := sql.Open("sqlite", dsn(path))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open db: %w", err)
 	}
 	if err := migrate(db); err != nil {
+		_ = db.Close()
 		return nil, err
 	}
-	return &Store{db: db}, nil
+	return &Store{conn: db, db: db}, nil
+}
+
+// dsn builds the modernc sqlite connection string. The pragmas are applied to
+// every pooled connection: busy_timeout first so that switching to WAL also
+// waits on other writers, and immediate transactions so a read-then-write
+// transaction never fails on lock upgrade.
+func dsn(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeoutMillis))
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Set("_txlock", "immediate")
+	return path + "?" + params.Encode()
+}
+
+func (s *Store) Close() error {
+	if s.inTx {
+		return fmt.Errorf("cannot close store inside a transaction")
+	}
+	return s.conn.Close()
+}
+
+// InTx runs fn against a Store bound to a single transaction. The transaction
+// commits when fn returns nil and rolls back otherwise. Nested calls reuse the
+// outer transaction.
+func (s *Store) InTx(fn func(tx *Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(&Store{conn: s.conn, db: tx, inTx: true}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 func migrate(db *sql.DB) error {
@@ -47,6 +104,30 @@ func migrate(db *sql.DB) error {
 			payload_json TEXT NOT NULL,
 			rendered_preview TEXT NOT NULL
 		);`,
+		`CREATE TABLE IF NOT EXISTS queue_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			scope TEXT NOT NULL,
+			taken_at DATETIME NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_queue_snapshots_scope ON queue_snapshots (scope, id);`,
+		`CREATE TABLE IF NOT EXISTS queue_snapshot_items (
+			snapshot_id INTEGER NOT NULL,
+			pr_id TEXT NOT NULL,
+			repo TEXT NOT NULL,
+			number INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			head_sha TEXT NOT NULL,
+			checks TEXT NOT NULL,
+			PRIMARY KEY (snapshot_id, pr_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS repo_configs (
+			repo TEXT NOT NULL,
+			sha TEXT NOT NULL,
+			path TEXT NOT NULL,
+			content TEXT NOT NULL,
+			fetched_at DATETIME NOT NULL,
+			PRIMARY KEY (repo, sha)
+		);`,
 	}
 	for _, stmt := range stmts {
 		if _, err := db.Exec(stmt); err != nil {
@@ -2,8 +2,12 @@ package store
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestDraftReviewCRUDAndPRState(t *testing.T) {
@@ -64,3 +68,185 @@ func TestDraftReviewCRUDAndPRState(t *testing.T) {
 		t.Fatalf("expected sql.ErrNoRows, got %v", err)
 	}
 }
+
+func TestConcurrentStoresOnSameFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prq.db")
+
+	// Separate Store instances stand in for separate prq processes sharing
+	// ~/.prq/prq.db (e.g. `prq queue` running alongside `prq review`).
+	const workers = 8
+	const iterations = 25
+	stores := make([]*Store, workers)
+	for i := range stores {
+		st, err := Open(path)
+		if err != nil {
+			t.Fatalf("open store %d: %v", i, err)
+		}
+		defer st.Close()
+		stores[i] = st
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*iterations)
+	for w, st := range stores {
+		wg.Add(1)
+		go func(w int, st *Store) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				prID := fmt.Sprintf("acme/app#%d", i%5)
+				head := fmt.Sprintf("head-%d-%d", w, i)
+				err := st.InTx(func(tx *Store) error {
+					if err := tx.UpsertPR(prID, "acme/app", i%5, head); err != nil {
+						return err
+					}
+					if err := tx.MarkReviewed(prID, head); err != nil {
+						return err
+					}
+					return tx.UpsertDraftReview(prID, `{"ok":true}`, "preview")
+				})
+				if err != nil {
+					errs <- fmt.Errorf("worker %d iteration %d: %w", w, i, err)
+					continue
+				}
+				if _, err := st.GetPR(prID); err != nil {
+					errs <- fmt.Errorf("worker %d read: %w", w, err)
+				}
+			}
+		}(w, st)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		prID := fmt.Sprintf("acme/app#%d", i)
+		pr, err := stores[0].GetPR(prID)
+		if err != nil {
+			t.Fatalf("get pr %s: %v", prID, err)
+		}
+		if !pr.LastReviewedHeadSHA.Valid || pr.LastReviewedHeadSHA.String != pr.LastSeenHeadSHA {
+			t.Fatalf("expected reviewed head to match seen head for %s, got %#v vs %q", prID, pr.LastReviewedHeadSHA, pr.LastSeenHeadSHA)
+		}
+	}
+}
+
+func TestInTxRollsBackOnError(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "prq.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	boom := errors.New("boom")
+	err = st.InTx(func(tx *Store) error {
+		if err := tx.UpsertPR("acme/app#1", "acme/app", 1, "headsha1"); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	if _, err := st.GetPR("acme/app#1"); err != sql.ErrNoRows {
+		t.Fatalf("expected rollback to discard pr, got %v", err)
+	}
+}
+
+func TestQueueSnapshots(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "prq.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	if _, err := st.LatestQueueSnapshot("review|"); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows before any fetch, got %v", err)
+	}
+
+	first := []QueueSnapshotItem{{PRID: "acme/app#1", Repo: "acme/app", Number: 1, Title: "One", HeadSHA: "h1", Checks: "success"}}
+	if _, err := st.RecordQueueSnapshot("review|", first); err != nil {
+		t.Fatalf("record snapshot: %v", err)
+	}
+	second := []QueueSnapshotItem{
+		{PRID: "acme/app#1", Repo: "acme/app", Number: 1, Title: "One", HeadSHA: "h2", Checks: "pending"},
+		{PRID: "acme/app#2", Repo: "acme/app", Number: 2, Title: "Two", HeadSHA: "h3", Checks: "none"},
+	}
+	if _, err := st.RecordQueueSnapshot("review|", second); err != nil {
+		t.Fatalf("record snapshot: %v", err)
+	}
+	if _, err := st.RecordQueueSnapshot("mine|", nil); err != nil {
+		t.Fatalf("record snapshot: %v", err)
+	}
+
+	snap, err := st.LatestQueueSnapshot("review|")
+	if err != nil {
+		t.Fatalf("latest snapshot: %v", err)
+	}
+	if len(snap.Items) != 2 || snap.Items[0].HeadSHA != "h2" || snap.Items[1].PRID != "acme/app#2" {
+		t.Fatalf("unexpected snapshot items: %#v", snap.Items)
+	}
+	if snap.TakenAt.IsZero() || time.Since(snap.TakenAt) > time.Hour {
+		t.Fatalf("expected taken_at to be read back, got %v", snap.TakenAt)
+	}
+
+	for i := 0; i < queueSnapshotsKept+5; i++ {
+		if _, err := st.RecordQueueSnapshot("review|", first); err != nil {
+			t.Fatalf("record snapshot: %v", err)
+		}
+	}
+	var count int
+	if err := st.conn.QueryRow(`SELECT COUNT(*) FROM queue_snapshots WHERE scope = ?`, "review|").Scan(&count); err != nil {
+		t.Fatalf("count snapshots: %v", err)
+	}
+	if count != queueSnapshotsKept {
+		t.Fatalf("expected %d snapshots after pruning, got %d", queueSnapshotsKept, count)
+	}
+
+	if err := st.UpsertPR("acme/lib#3", "acme/lib", 3, "h4"); err != nil {
+		t.Fatalf("upsert pr: %v", err)
+	}
+	if err := st.UpsertDraftReview("acme/lib#3", "{}", "preview"); err != nil {
+		t.Fatalf("upsert draft: %v", err)
+	}
+	if err := st.UpsertPR("acme/old#4", "acme/old", 4, "h5"); err != nil {
+		t.Fatalf("upsert pr: %v", err)
+	}
+	tracked, err := st.TrackedRepos()
+	if err != nil {
+		t.Fatalf("tracked repos: %v", err)
+	}
+	if len(tracked) != 2 || !tracked["acme/app"] || !tracked["acme/lib"] {
+		t.Fatalf("unexpected tracked repos: %v", tracked)
+	}
+}
+
+func TestRepoConfigCache(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "prq.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	if _, err := st.GetRepoConfig("acme/app", "base1234"); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows before any fetch, got %v", err)
+	}
+	if err := st.PutRepoConfig("acme/app", "base1234", "prq.yaml", "diff:\n  max_files: 10\n"); err != nil {
+		t.Fatalf("put repo config: %v", err)
+	}
+	if err := st.PutRepoConfig("acme/app", "other999", "", ""); err != nil {
+		t.Fatalf("put repo config: %v", err)
+	}
+	rc, err := st.GetRepoConfig("acme/app", "base1234")
+	if err != nil {
+		t.Fatalf("get repo config: %v", err)
+	}
+	if rc.Path != "prq.yaml" || rc.Content != "diff:\n  max_files: 10\n" || rc.FetchedAt.IsZero() {
+		t.Fatalf("unexpected repo config: %#v", rc)
+	}
+	rc, err = st.GetRepoConfig("acme/app", "other999")
+	if err != nil || rc.Path != "" {
+		t.Fatalf("expected a cached miss, got %#v (%v)", rc, err)
+	}
+}
@@ -0,0 +1,125 @@
+package testreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// IsGoTestJSON reports whether command runs go test with -json output.
+func IsGoTestJSON(command string) bool {
+	fields := strings.Fields(command)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "go" && fields[i+1] == "test" {
+			for _, f := range fields[i+2:] {
+				if f == "-json" || f == "--json" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+type goTestEvent struct {
+	Action  string `json:"Action"`
+	Package string `json:"Package"`
+	Test    string `json:"Test"`
+	Output  string `json:"Output"`
+}
+
+// GoTestParser consumes a `go test -json` stream as an io.Writer, so the full
+// output can be parsed while only a truncated copy is kept.
+type GoTestParser struct {
+	partial []byte
+	counts  Counts
+	seen    bool
+	// output buffers the lines of each running test or package, bounded by
+	// maxFailureLines, and is dropped once the test passes.
+	output   map[string][]string
+	failures []Failure
+	// failedTests tracks packages with a failing test, so the package-level
+	// failure that follows is not reported twice.
+	failedTests map[string]bool
+}
+
+func NewGoTestParser() *GoTestParser {
+	return &GoTestParser{output: map[string][]string{}, failedTests: map[string]bool{}}
+}
+
+func (p *GoTestParser) Write(data []byte) (int, error) {
+	p.partial = append(p.partial, data...)
+	for {
+		idx := bytes.IndexByte(p.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		p.handleLine(p.partial[:idx])
+		p.partial = p.partial[idx+1:]
+	}
+	return len(data), nil
+}
+
+func (p *GoTestParser) handleLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return
+	}
+	var ev goTestEvent
+	if err := json.Unmarshal(line, &ev); err != nil || ev.Action == "" {
+		return
+	}
+	p.seen = true
+	key := ev.Package + "\x00" + ev.Test
+	switch ev.Action {
+	case "output":
+		if len(p.output[key]) < maxFailureLines+1 {
+			p.output[key] = append(p.output[key], strings.TrimRight(ev.Output, "\n"))
+		}
+	case "pass":
+		if ev.Test != "" {
+			p.counts.Passed++
+		}
+		delete(p.output, key)
+	case "skip":
+		if ev.Test != "" {
+			p.counts.Skipped++
+		}
+		delete(p.output, key)
+	case "fail":
+		if ev.Test != "" {
+			p.counts.Failed++
+			p.failedTests[ev.Package] = true
+			p.failures = append(p.failures, Failure{Package: ev.Package, Test: ev.Test, Message: failureMessage(p.output[key])})
+		} else if !p.failedTests[ev.Package] {
+			p.failures = append(p.failures, Failure{Package: ev.Package, Message: failureMessage(p.output[key])})
+		}
+		delete(p.output, key)
+	}
+}
+
+// Result returns the parsed counts and failures; ok is false when no go test
+// events were seen.
+func (p *GoTestParser) Result() (Counts, []Failure, bool) {
+	if len(p.partial) > 0 {
+		p.handleLine(p.partial)
+		p.partial = nil
+	}
+	failures := append([]Failure(nil), p.failures...)
+	sort.SliceStable(failures, func(i, j int) bool { return failures[i].Package < failures[j].Package })
+	return p.counts, failures, p.seen
+}
+
+// failureMessage drops go test's framing lines and keeps the assertion output.
+func failureMessage(lines []string) string {
+	var kept []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- FAIL") || trimmed == "FAIL" || strings.HasPrefix(trimmed, "FAIL\t") {
+			continue
+		}
+		kept = append(kept, trimmed)
+	}
+	return clipLines(strings.Join(kept, "\n"), maxFailureLines)
+}
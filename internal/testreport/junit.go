@@ -0,0 +1,109 @@
+package testreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// JUnit documents are either a <testsuites> wrapper or a single <testsuite>.
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name   string        `xml:"name,attr"`
+	Cases  []junitCase   `xml:"testcase"`
+	Suites []junitSuite  `xml:"testsuite"`
+	Error  *junitFailure `xml:"error"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+	Skipped   *struct{}     `xml:"skipped"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// ParseJUnit reads a JUnit XML report.
+func ParseJUnit(data []byte) (Counts, []Failure, error) {
+	var suites []junitSuite
+	trimmed := strings.TrimSpace(string(data))
+	var wrapper junitSuites
+	if err := xml.Unmarshal([]byte(trimmed), &wrapper); err == nil {
+		suites = wrapper.Suites
+	} else {
+		var single junitSuite
+		if err := xml.Unmarshal([]byte(trimmed), &single); err != nil {
+			return Counts{}, nil, fmt.Errorf("parse junit xml: %w", err)
+		}
+		suites = []junitSuite{single}
+	}
+	var counts Counts
+	var failures []Failure
+	var walk func(suite junitSuite)
+	walk = func(suite junitSuite) {
+		for _, tc := range suite.Cases {
+			pkg := tc.Classname
+			if pkg == "" {
+				pkg = suite.Name
+			}
+			switch {
+			case tc.Failure != nil:
+				counts.Failed++
+				failures = append(failures, Failure{Package: pkg, Test: tc.Name, Message: junitMessage(tc.Failure)})
+			case tc.Error != nil:
+				counts.Failed++
+				failures = append(failures, Failure{Package: pkg, Test: tc.Name, Message: junitMessage(tc.Error)})
+			case tc.Skipped != nil:
+				counts.Skipped++
+			default:
+				counts.Passed++
+			}
+		}
+		if suite.Error != nil {
+			failures = append(failures, Failure{Package: suite.Name, Message: junitMessage(suite.Error)})
+		}
+		for _, nested := range suite.Suites {
+			walk(nested)
+		}
+	}
+	for _, suite := range suites {
+		walk(suite)
+	}
+	return counts, failures, nil
+}
+
+func junitMessage(f *junitFailure) string {
+	parts := []string{}
+	if msg := strings.TrimSpace(f.Message); msg != "" {
+		parts = append(parts, msg)
+	}
+	if body := strings.TrimSpace(f.Body); body != "" && body != strings.TrimSpace(f.Message) {
+		parts = append(parts, body)
+	}
+	return clipLines(strings.Join(parts, "\n"), maxFailureLines)
+}
+
+// Merge adds parsed counts and failures to an existing result.
+func (r *CommandResult) Merge(format string, counts Counts, failures []Failure) {
+	if r.Format == "" {
+		r.Format = format
+	} else if r.Format != format {
+		r.Format = r.Format + "+" + format
+	}
+	if r.Tests == nil {
+		r.Tests = &Counts{}
+	}
+	r.Tests.Passed += counts.Passed
+	r.Tests.Failed += counts.Failed
+	r.Tests.Skipped += counts.Skipped
+	r.Failures = append(r.Failures, failures...)
+}
@@ -0,0 +1,184 @@
+// Package testreport turns the output of repo test commands into structured
+// per-command results and a compact summary for the review prompt.
+package testreport
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// maxFailureLines caps the output kept for one failing test.
+	maxFailureLines = 20
+	// maxFailures caps the failures listed per command in the summary.
+	maxFailures = 20
+	// tailLines is how much unparsed output the summary keeps per stream.
+	tailLines = 15
+)
+
+// Parser formats recognised in command output.
+const (
+	FormatGoTestJSON = "go-test-json"
+	FormatJUnit      = "junit"
+)
+
+// CommandResult is the outcome of one tests.commands entry.
+type CommandResult struct {
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+	// Stdout and Stderr are already truncated to tests.max_output_bytes.
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+	// Format is the parser that produced Tests, empty if none applied.
+	Format string  `json:"format,omitempty"`
+	Tests  *Counts `json:"tests,omitempty"`
+	// Failures lists failing tests or packages found by the parser.
+	Failures []Failure `json:"failures,omitempty"`
+	// Error is set when the command could not be started.
+	Error string `json:"error,omitempty"`
+	// SkipReason is set when the command was not run.
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
+// Counts tallies individual test outcomes.
+type Counts struct {
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// Failure is one failing test, or a package that failed without a test (such
+// as a build error).
+type Failure struct {
+	Package string `json:"package,omitempty"`
+	Test    string `json:"test,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Passed reports whether the command exited zero and no failures were parsed.
+func (r CommandResult) Passed() bool {
+	return r.Error == "" && !r.TimedOut && r.ExitCode == 0 && len(r.Failures) == 0
+}
+
+// Status is PASS, FAIL, TIMEOUT, ERROR, or SKIP.
+func (r CommandResult) Status() string {
+	switch {
+	case r.SkipReason != "":
+		return "SKIP"
+	case r.Error != "":
+		return "ERROR"
+	case r.TimedOut:
+		return "TIMEOUT"
+	case r.Passed():
+		return "PASS"
+	default:
+		return "FAIL"
+	}
+}
+
+// Name identifies a failure as "package TestName".
+func (f Failure) Name() string {
+	switch {
+	case f.Package != "" && f.Test != "":
+		return f.Package + " " + f.Test
+	case f.Test != "":
+		return f.Test
+	default:
+		return f.Package
+	}
+}
+
+// Summarize renders results compactly for the prompt: one status line per
+// command, parsed failures with their messages, and the tail of unparsed
+// output.
+func Summarize(results []CommandResult) string {
+	if len(results) == 0 {
+		return "No test commands were run."
+	}
+	var b strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "$ %s\n", r.Command)
+		if r.SkipReason != "" {
+			fmt.Fprintf(&b, "SKIP (%s)\n", r.SkipReason)
+			continue
+		}
+		fmt.Fprintf(&b, "%s (exit %d, %s)", r.Status(), r.ExitCode, FormatDuration(r.Duration))
+		if r.Tests != nil {
+			fmt.Fprintf(&b, " %s", r.Tests)
+		}
+		b.WriteString("\n")
+		if r.Error != "" {
+			fmt.Fprintf(&b, "Error: %s\n", r.Error)
+			continue
+		}
+		for n, f := range r.Failures {
+			if n == maxFailures {
+				fmt.Fprintf(&b, "... %d more failures\n", len(r.Failures)-maxFailures)
+				break
+			}
+			fmt.Fprintf(&b, "FAIL %s\n", f.Name())
+			if f.Message != "" {
+				b.WriteString(indent(f.Message, "    "))
+			}
+		}
+		if r.Format == "" {
+			writeTail(&b, "stdout", r.Stdout)
+			writeTail(&b, "stderr", r.Stderr)
+		} else if !r.Passed() && len(r.Failures) == 0 {
+			writeTail(&b, "stderr", r.Stderr)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (c *Counts) String() string {
+	return fmt.Sprintf("%d passed, %d failed, %d skipped", c.Passed, c.Failed, c.Skipped)
+}
+
+// FormatDuration rounds d for display.
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func writeTail(b *strings.Builder, label string, text string) {
+	text = strings.TrimRight(text, "\n")
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > tailLines {
+		fmt.Fprintf(b, "%s (last %d of %d lines):\n", label, tailLines, len(lines))
+		lines = lines[len(lines)-tailLines:]
+	} else {
+		fmt.Fprintf(b, "%s:\n", label)
+	}
+	b.WriteString(indent(strings.Join(lines, "\n"), "    "))
+}
+
+func indent(text string, prefix string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		b.WriteString(prefix)
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// clipLines keeps the first limit lines of text.
+func clipLines(text string, limit int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) <= limit {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(lines[:limit], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-limit)
+}
@@ -0,0 +1,116 @@
+package testreport
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const goTestStream = `{"Action":"run","Package":"example.com/auth","Test":"TestLogin"}
+{"Action":"output","Package":"example.com/auth","Test":"TestLogin","Output":"=== RUN   TestLogin\n"}
+{"Action":"output","Package":"example.com/auth","Test":"TestLogin","Output":"    auth_test.go:12: expected nil error, got denied\n"}
+{"Action":"output","Package":"example.com/auth","Test":"TestLogin","Output":"--- FAIL: TestLogin (0.00s)\n"}
+{"Action":"fail","Package":"example.com/auth","Test":"TestLogin","Elapsed":0}
+{"Action":"run","Package":"example.com/auth","Test":"TestLogout"}
+{"Action":"pass","Package":"example.com/auth","Test":"TestLogout","Elapsed":0}
+{"Action":"skip","Package":"example.com/auth","Test":"TestSlow","Elapsed":0}
+{"Action":"output","Package":"example.com/auth","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/auth","Elapsed":0.01}
+{"Action":"output","Package":"example.com/broken","Output":"broken.go:3:1: syntax error\n"}
+{"Action":"fail","Package":"example.com/broken","Elapsed":0}
+`
+
+func TestGoTestParser(t *testing.T) {
+	parser := NewGoTestParser()
+	// Split mid-line to exercise buffering.
+	half := len(goTestStream) / 2
+	_, _ = parser.Write([]byte(goTestStream[:half]))
+	_, _ = parser.Write([]byte(goTestStream[half:]))
+
+	counts, failures, ok := parser.Result()
+	if !ok {
+		t.Fatalf("expected go test events")
+	}
+	if counts != (Counts{Passed: 1, Failed: 1, Skipped: 1}) {
+		t.Fatalf("unexpected counts: %+v", counts)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected a test failure and a package failure, got %+v", failures)
+	}
+	if failures[0].Name() != "example.com/auth TestLogin" || failures[0].Message != "auth_test.go:12: expected nil error, got denied" {
+		t.Fatalf("unexpected test failure: %+v", failures[0])
+	}
+	if failures[1].Name() != "example.com/broken" || failures[1].Message != "broken.go:3:1: syntax error" {
+		t.Fatalf("unexpected package failure: %+v", failures[1])
+	}
+}
+
+func TestGoTestParserIgnoresPlainOutput(t *testing.T) {
+	parser := NewGoTestParser()
+	_, _ = parser.Write([]byte("ok  \texample.com/auth\t0.01s\n"))
+	if _, _, ok := parser.Result(); ok {
+		t.Fatalf("expected no go test events")
+	}
+}
+
+func TestIsGoTestJSON(t *testing.T) {
+	cases := map[string]bool{
+		"go test -json ./...":              true,
+		"cd svc && go test -race -json ./": true,
+		"go test ./...":                    false,
+		"gotestsum --jsonfile out.json":    false,
+	}
+	for command, want := range cases {
+		if got := IsGoTestJSON(command); got != want {
+			t.Fatalf("IsGoTestJSON(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
+
+func TestParseJUnit(t *testing.T) {
+	report := `<?xml version="1.0"?>
+<testsuites>
+  <testsuite name="auth">
+    <testcase classname="auth.LoginTest" name="rejects_empty"><failure message="expected error">stack trace</failure></testcase>
+    <testcase classname="auth.LoginTest" name="accepts_user"/>
+    <testcase classname="auth.LoginTest" name="slow"><skipped/></testcase>
+  </testsuite>
+</testsuites>`
+	counts, failures, err := ParseJUnit([]byte(report))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counts != (Counts{Passed: 1, Failed: 1, Skipped: 1}) {
+		t.Fatalf("unexpected counts: %+v", counts)
+	}
+	if len(failures) != 1 || failures[0].Name() != "auth.LoginTest rejects_empty" || failures[0].Message != "expected error\nstack trace" {
+		t.Fatalf("unexpected failures: %+v", failures)
+	}
+
+	single := `<testsuite name="api"><testcase name="ok"/></testsuite>`
+	counts, _, err = ParseJUnit([]byte(single))
+	if err != nil || counts.Passed != 1 {
+		t.Fatalf("expected a bare testsuite to parse, got %+v, %v", counts, err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	results := []CommandResult{
+		{Command: "go test -json ./...", ExitCode: 1, Duration: 2300 * time.Millisecond, Format: FormatGoTestJSON, Tests: &Counts{Passed: 4, Failed: 1}, Failures: []Failure{{Package: "auth", Test: "TestLogin", Message: "boom"}}, Stdout: "{\"Action\":\"output\"}"},
+		{Command: "make lint", ExitCode: 0, Duration: 40 * time.Millisecond, Stdout: "lint ok\n"},
+		{Command: "sleep 100", ExitCode: -1, TimedOut: true},
+	}
+	out := Summarize(results)
+	for _, want := range []string{
+		"$ go test -json ./...\nFAIL (exit 1, 2.3s) 4 passed, 1 failed, 0 skipped\nFAIL auth TestLogin\n    boom",
+		"$ make lint\nPASS (exit 0, 40ms)\nstdout:\n    lint ok",
+		"$ sleep 100\nTIMEOUT (exit -1, 0s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Action") {
+		t.Fatalf("expected parsed stdout to be omitted:\n%s", out)
+	}
+}